@@ -288,30 +288,9 @@ func (i *imagesTemplateParams) HeaderMap() map[string]string {
 // CreateFilterFuncs returns an array of filter functions based on the user inputs
 // and is later used to filter images for output
 func CreateFilterFuncs(ctx context.Context, r *libpod.Runtime, c *cli.Context, img *image.Image) ([]image.ResultFilter, error) {
-	var filterFuncs []image.ResultFilter
-	for _, filter := range c.StringSlice("filter") {
-		splitFilter := strings.Split(filter, "=")
-		switch splitFilter[0] {
-		case "before":
-			before, err := r.ImageRuntime().NewFromLocal(splitFilter[1])
-			if err != nil {
-				return nil, errors.Wrapf(err, "unable to find image % in local stores", splitFilter[1])
-			}
-			filterFuncs = append(filterFuncs, image.CreatedBeforeFilter(before.Created()))
-		case "after":
-			after, err := r.ImageRuntime().NewFromLocal(splitFilter[1])
-			if err != nil {
-				return nil, errors.Wrapf(err, "unable to find image % in local stores", splitFilter[1])
-			}
-			filterFuncs = append(filterFuncs, image.CreatedAfterFilter(after.Created()))
-		case "dangling":
-			filterFuncs = append(filterFuncs, image.DanglingFilter())
-		case "label":
-			labelFilter := strings.Join(splitFilter[1:], "=")
-			filterFuncs = append(filterFuncs, image.LabelFilter(ctx, labelFilter))
-		default:
-			return nil, errors.Errorf("invalid filter %s ", splitFilter[0])
-		}
+	filterFuncs, err := r.ImageRuntime().CreateFilters(ctx, c.StringSlice("filter"))
+	if err != nil {
+		return nil, err
 	}
 	if img != nil {
 		filterFuncs = append(filterFuncs, image.OutputImageFilter(img))
@@ -27,6 +27,10 @@ var (
 			Name:  "compress",
 			Usage: "compress tarball image layers when saving to a directory using the 'dir' transport. (default is same compression type as source)",
 		},
+		cli.BoolFlag{
+			Name:  "deterministic",
+			Usage: "normalize the archive so that saving the same image again produces identical bytes",
+		},
 		cli.StringFlag{
 			Name:  "output, o",
 			Usage: "Write to a file, default is STDOUT",
@@ -76,6 +80,10 @@ func saveCmd(c *cli.Context) error {
 		return errors.Errorf("--compress can only be set when --format is either 'oci-dir' or 'docker-dir'")
 	}
 
+	if c.Bool("deterministic") && (c.String("format") == ociManifestDir || c.String("format") == v2s2ManifestDir) {
+		return errors.Errorf("--deterministic can only be set when --format is either 'docker-archive' or 'oci-archive'")
+	}
+
 	var writer io.Writer
 	if !c.Bool("quiet") {
 		writer = os.Stderr
@@ -127,7 +135,12 @@ func saveCmd(c *cli.Context) error {
 	if (strings.Contains(dst, libpod.OCIArchive) || strings.Contains(dst, libpod.DockerArchive)) && !strings.Contains(newImage.ID(), args[0]) {
 		dest = dst + ":" + args[0]
 	}
-	if err := newImage.PushImage(getContext(), dest, manifestType, "", "", writer, c.Bool("compress"), libpodImage.SigningOptions{}, &libpodImage.DockerRegistryOptions{}, false, additionaltags); err != nil {
+	if c.Bool("deterministic") {
+		err = newImage.ExportDeterministic(getContext(), dest, manifestType, "", writer, additionaltags)
+	} else {
+		err = newImage.PushImage(getContext(), dest, manifestType, "", "", writer, c.Bool("compress"), libpodImage.SigningOptions{}, &libpodImage.DockerRegistryOptions{}, false, additionaltags)
+	}
+	if err != nil {
 		if err2 := os.Remove(output); err2 != nil {
 			logrus.Errorf("error deleting %q: %v", output, err)
 		}
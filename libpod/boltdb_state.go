@@ -538,6 +538,59 @@ func (s *BoltState) SaveContainer(ctr *Container) error {
 	return err
 }
 
+// RewriteContainerConfig replaces a container's configuration in the database
+func (s *BoltState) RewriteContainerConfig(ctr *Container, newCfg *ContainerConfig) error {
+	if !s.valid {
+		return ErrDBClosed
+	}
+
+	if !ctr.valid {
+		return ErrCtrRemoved
+	}
+
+	if newCfg.ID != ctr.ID() || newCfg.Name != ctr.Name() {
+		return errors.Wrapf(ErrInvalidArg, "cannot change the ID or name of container %s", ctr.ID())
+	}
+
+	configJSON, err := json.Marshal(newCfg)
+	if err != nil {
+		return errors.Wrapf(err, "error marshalling container %s config to JSON", ctr.ID())
+	}
+
+	ctrID := []byte(ctr.ID())
+
+	db, err := s.getDBCon()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		ctrBucket, err := getCtrBucket(tx)
+		if err != nil {
+			return err
+		}
+
+		ctrToUpdate := ctrBucket.Bucket(ctrID)
+		if ctrToUpdate == nil {
+			ctr.valid = false
+			return errors.Wrapf(ErrNoSuchCtr, "container %s does not exist in DB", ctr.ID())
+		}
+
+		if err := ctrToUpdate.Put(configKey, configJSON); err != nil {
+			return errors.Wrapf(err, "error updating container %s config in DB", ctr.ID())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	ctr.config = newCfg
+	return nil
+}
+
 // ContainerInUse checks if other containers depend on the given container
 // It returns a slice of the IDs of the containers depending on the given
 // container. If the slice is empty, no containers depend on the given container
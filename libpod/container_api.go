@@ -2,6 +2,7 @@ package libpod
 
 import (
 	"context"
+	"io"
 	"io/ioutil"
 	"os"
 	"strconv"
@@ -269,6 +270,13 @@ func (c *Container) Kill(signal uint) error {
 // TODO allow specifying streams to attach to
 // TODO investigate allowing exec without attaching
 func (c *Container) Exec(tty, privileged bool, env, cmd []string, user string) error {
+	return c.exec(tty, privileged, env, cmd, user, nil)
+}
+
+// exec runs a command in the container, as Exec does.  If output is not nil,
+// the command's standard output and error are written to it instead of ours,
+// and it gets no input.
+func (c *Container) exec(tty, privileged bool, env, cmd []string, user string, output io.Writer) error {
 	var capList []string
 
 	locked := false
@@ -321,6 +329,11 @@ func (c *Container) Exec(tty, privileged bool, env, cmd []string, user string) e
 	if err != nil {
 		return errors.Wrapf(err, "error creating exec command for container %s", c.ID())
 	}
+	if output != nil {
+		execCmd.Stdin = nil
+		execCmd.Stdout = output
+		execCmd.Stderr = output
+	}
 
 	if err := execCmd.Start(); err != nil {
 		return errors.Wrapf(err, "error starting exec command for container %s", c.ID())
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	is "github.com/containers/image/storage"
+	"github.com/containers/image/types"
 	"github.com/pkg/errors"
 	"github.com/projectatomic/buildah"
 	"github.com/projectatomic/buildah/util"
@@ -157,6 +158,51 @@ func (c *Container) Commit(ctx context.Context, destImage string, options Contai
 			importBuilder.SetWorkDir(splitChange[1])
 		}
 	}
+	imageRef, err := c.commitReference(destImage, sc)
+	if err != nil {
+		return nil, err
+	}
+	id, err := importBuilder.Commit(ctx, imageRef, commitOptions)
+	if err != nil {
+		return nil, err
+	}
+	return c.runtime.imageRuntime.NewFromLocal(id)
+}
+
+// CommitToNames commits the changes between a container and its image to a
+// single new image, as Commit does, and names the image with each of
+// destImages.  All the names are checked before committing, and the names
+// after the first are added to the image at once.  The full list of the
+// image's names is available from the returned image.
+func (c *Container) CommitToNames(ctx context.Context, destImages []string, options ContainerCommitOptions) (*image.Image, error) {
+	if len(destImages) == 0 {
+		return nil, errors.Wrapf(ErrInvalidArg, "no image names given to commit container %s to", c.ID())
+	}
+	sc := image.GetSystemContext(options.SignaturePolicyPath, "", false)
+	var names []string
+	for _, destImage := range destImages {
+		imageRef, err := c.commitReference(destImage, sc)
+		if err != nil {
+			return nil, err
+		}
+		if imageRef.DockerReference() == nil {
+			return nil, errors.Errorf("%q is not a valid image name", destImage)
+		}
+		names = append(names, imageRef.DockerReference().String())
+	}
+
+	newImage, err := c.Commit(ctx, destImages[0], options)
+	if err != nil {
+		return nil, err
+	}
+	if err := newImage.TagImages(names[1:]); err != nil {
+		return nil, errors.Wrapf(err, "error naming image %s", newImage.ID())
+	}
+	return newImage, nil
+}
+
+// commitReference resolves the name of an image to commit a container to
+func (c *Container) commitReference(destImage string, sc *types.SystemContext) (types.ImageReference, error) {
 	candidates := util.ResolveName(destImage, "", sc, c.runtime.store)
 	if len(candidates) == 0 {
 		return nil, errors.Errorf("error parsing target image name %q", destImage)
@@ -165,9 +211,5 @@ func (c *Container) Commit(ctx context.Context, destImage string, options Contai
 	if err != nil {
 		return nil, errors.Wrapf(err, "error parsing target image name %q", destImage)
 	}
-	id, err := importBuilder.Commit(ctx, imageRef, commitOptions)
-	if err != nil {
-		return nil, err
-	}
-	return c.runtime.imageRuntime.NewFromLocal(id)
+	return imageRef, nil
 }
@@ -34,7 +34,10 @@ func buildContainerGraph(ctrs []*Container) (*containerGraph, error) {
 	}
 
 	// Now add edges based on dependencies
-	for _, node := range graph.nodes {
+	// Go through the containers in the order given, so the order of the
+	// nodes without dependencies does not vary between calls
+	for _, ctr := range ctrs {
+		node := graph.nodes[ctr.ID()]
 		deps := node.container.Dependencies()
 		for _, dep := range deps {
 			// Get the dep's node
@@ -169,3 +172,33 @@ func detectCycles(graph *containerGraph) (bool, error) {
 
 	return false, nil
 }
+
+// startOrder returns the IDs of the containers in the graph in the order
+// Pod.Start starts them: each container after all its dependencies
+func (graph *containerGraph) startOrder() []string {
+	order := make([]string, 0, len(graph.nodes))
+	visited := make(map[string]bool)
+
+	var visit func(*containerNode)
+	visit = func(node *containerNode) {
+		if visited[node.id] {
+			return
+		}
+		for _, dep := range node.dependsOn {
+			if !visited[dep.id] {
+				// We will be visited again once the dependency is
+				return
+			}
+		}
+		visited[node.id] = true
+		order = append(order, node.id)
+		for _, successor := range node.dependedOn {
+			visit(successor)
+		}
+	}
+
+	for _, node := range graph.noDepNodes {
+		visit(node)
+	}
+	return order
+}
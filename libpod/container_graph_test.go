@@ -291,3 +291,34 @@ func TestBuildContainerGraphFourContainersNoneInCycle(t *testing.T) {
 	assert.Equal(t, 2, len(graph.noDepNodes))
 	assert.Equal(t, 2, len(graph.notDependedOnNodes))
 }
+
+func TestContainerGraphStartOrder(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", tmpDirPrefix)
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	ctr1, err := getTestCtr1(tmpDir)
+	assert.NoError(t, err)
+	ctr2, err := getTestCtr2(tmpDir)
+	assert.NoError(t, err)
+	ctr3, err := getTestCtrN("3", tmpDir)
+	assert.NoError(t, err)
+	ctr4, err := getTestCtrN("4", tmpDir)
+	assert.NoError(t, err)
+	ctr1.config.IPCNsCtr = ctr2.config.ID
+	ctr1.config.NetNsCtr = ctr3.config.ID
+	ctr2.config.UserNsCtr = ctr3.config.ID
+
+	graph, err := buildContainerGraph([]*Container{ctr1, ctr2, ctr3, ctr4})
+	assert.NoError(t, err)
+	order := graph.startOrder()
+	assert.Equal(t, []string{ctr3.ID(), ctr2.ID(), ctr1.ID(), ctr4.ID()}, order)
+
+	// A container shared by several dependents is only listed once, after
+	// all its dependencies
+	ctr4.config.MountNsCtr = ctr1.config.ID
+	ctr4.config.UTSNsCtr = ctr3.config.ID
+	graph, err = buildContainerGraph([]*Container{ctr1, ctr2, ctr3, ctr4})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{ctr3.ID(), ctr2.ID(), ctr1.ID(), ctr4.ID()}, graph.startOrder())
+}
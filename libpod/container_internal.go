@@ -435,6 +435,9 @@ func (c *Container) save() error {
 	if err := c.runtime.state.SaveContainer(c); err != nil {
 		return errors.Wrapf(err, "error saving container %s state", c.ID())
 	}
+	if c.config.Pod != "" {
+		publishPodEvent(c.config.Pod, PodEventContainerState, c.ID(), c.state.State.String())
+	}
 	return nil
 }
 
@@ -56,6 +56,14 @@ var (
 	// further operations can be performed on it
 	ErrPodRemoved = errors.New("pod has already been removed")
 
+	// ErrImageUsedByPods indicates that an image could not be removed as
+	// it backs containers of active pods
+	ErrImageUsedByPods = errors.New("image is in use by active pods")
+
+	// ErrNoHealthcheck indicates that a container has no healthcheck
+	// defined by its image
+	ErrNoHealthcheck = errors.New("container has no healthcheck")
+
 	// ErrDBClosed indicates that the connection to the state database has
 	// already been closed
 	ErrDBClosed = errors.New("database connection already closed")
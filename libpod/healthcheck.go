@@ -0,0 +1,104 @@
+package libpod
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"syscall"
+
+	"github.com/containers/image/manifest"
+	"github.com/pkg/errors"
+)
+
+// HealthcheckResult is the outcome of running a container's healthcheck
+type HealthcheckResult struct {
+	// ExitCode is the exit code of the check; 0 means the container is
+	// healthy
+	ExitCode int
+	// Output is the combined standard output and error of the check
+	Output string
+}
+
+// RunPodContainerHealthcheck runs the healthcheck of a container in a pod
+// immediately, rather than waiting for it to be scheduled, and returns its
+// result.  The container is named by name or ID and must be running.  The
+// healthcheck is the one defined by the container's image.  Its timeout and
+// retries are not applied: the check is run once, to completion.
+func (r *Runtime) RunPodContainerHealthcheck(ctx context.Context, podName, ctrName string) (*HealthcheckResult, error) {
+	pod, err := r.LookupPod(podName)
+	if err != nil {
+		return nil, err
+	}
+	ctrs, err := r.state.PodContainers(pod)
+	if err != nil {
+		return nil, err
+	}
+	var ctr *Container
+	for _, c := range ctrs {
+		if c.ID() == ctrName || c.Name() == ctrName {
+			ctr = c
+			break
+		}
+	}
+	if ctr == nil {
+		return nil, errors.Wrapf(ErrNoSuchCtr, "pod %s has no container %s", pod.ID(), ctrName)
+	}
+
+	state, err := ctr.State()
+	if err != nil {
+		return nil, err
+	}
+	if state != ContainerStateRunning {
+		return nil, errors.Wrapf(ErrCtrStateInvalid, "container %s is not running", ctr.ID())
+	}
+
+	imageID, _ := ctr.Image()
+	if imageID == "" {
+		return nil, errors.Wrapf(ErrNoHealthcheck, "container %s was not created from an image", ctr.ID())
+	}
+	img, err := r.imageRuntime.NewFromLocal(imageID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to find image of container %s", ctr.ID())
+	}
+	healthcheck, err := img.Healthcheck(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if healthcheck == nil {
+		return nil, errors.Wrapf(ErrNoHealthcheck, "%s", ctr.ID())
+	}
+	cmd, err := healthcheckCommand(healthcheck)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid healthcheck for container %s", ctr.ID())
+	}
+
+	var output bytes.Buffer
+	result := &HealthcheckResult{}
+	if err := ctr.exec(false, false, nil, cmd, "", &output); err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			return nil, errors.Wrapf(err, "unable to run healthcheck of container %s", ctr.ID())
+		}
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			result.ExitCode = status.ExitStatus()
+		} else {
+			result.ExitCode = 1
+		}
+	}
+	result.Output = output.String()
+	return result, nil
+}
+
+// healthcheckCommand returns the command to execute for a healthcheck
+func healthcheckCommand(healthcheck *manifest.Schema2HealthConfig) ([]string, error) {
+	if len(healthcheck.Test) < 2 {
+		return nil, errors.Wrapf(ErrInvalidArg, "healthcheck test %v has no command", healthcheck.Test)
+	}
+	switch healthcheck.Test[0] {
+	case "CMD":
+		return healthcheck.Test[1:], nil
+	case "CMD-SHELL":
+		return []string{"/bin/sh", "-c", healthcheck.Test[1]}, nil
+	}
+	return nil, errors.Wrapf(ErrInvalidArg, "unknown healthcheck test type %q", healthcheck.Test[0])
+}
@@ -0,0 +1,30 @@
+package libpod
+
+import (
+	"testing"
+
+	"github.com/containers/image/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthcheckCommand(t *testing.T) {
+	tests := []struct {
+		test    []string
+		cmd     []string
+		wantErr bool
+	}{
+		{[]string{"CMD", "/bin/check", "--quick"}, []string{"/bin/check", "--quick"}, false},
+		{[]string{"CMD-SHELL", "curl -f http://localhost/ || exit 1"}, []string{"/bin/sh", "-c", "curl -f http://localhost/ || exit 1"}, false},
+		{[]string{"CMD"}, nil, true},
+		{[]string{"RUN", "/bin/check"}, nil, true},
+	}
+	for _, test := range tests {
+		cmd, err := healthcheckCommand(&manifest.Schema2HealthConfig{Test: test.test})
+		if test.wantErr {
+			assert.Error(t, err, "%v", test.test)
+			continue
+		}
+		assert.NoError(t, err, "%v", test.test)
+		assert.Equal(t, test.cmd, cmd)
+	}
+}
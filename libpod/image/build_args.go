@@ -0,0 +1,89 @@
+package image
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// BuildArgs returns the build arguments recorded in the image's history, by
+// name.  Builds record the values of the arguments in effect for each RUN
+// instruction, and the defaults given by ARG instructions; a value used by a
+// RUN instruction takes precedence over a default.  Only what the history
+// records is returned: arguments which were never used, or whose values the
+// builder chose not to record, are missing, and values containing whitespace
+// cannot be recovered in full.
+func (i *Image) BuildArgs(ctx context.Context) (map[string]string, error) {
+	history, _, err := i.History(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parseBuildArgs(history), nil
+}
+
+// parseBuildArgs extracts build arguments from the CreatedBy entries of an
+// image's history
+func parseBuildArgs(history []ociv1.History) map[string]string {
+	args := make(map[string]string)
+	defaults := make(map[string]string)
+	for _, entry := range history {
+		createdBy := strings.TrimSpace(entry.CreatedBy)
+		switch {
+		case strings.HasPrefix(createdBy, "|"):
+			// A RUN instruction, recorded as "|2 A=1 B=2 /bin/sh -c ..."
+			fields := strings.Fields(createdBy[1:])
+			if len(fields) == 0 {
+				continue
+			}
+			count, err := strconv.Atoi(fields[0])
+			if err != nil || count > len(fields)-1 {
+				continue
+			}
+			// If the count does not match the arguments, this is
+			// not a record of build arguments
+			runArgs := make(map[string]string)
+			for _, arg := range fields[1 : count+1] {
+				name, value, ok := splitBuildArg(arg)
+				if !ok {
+					runArgs = nil
+					break
+				}
+				runArgs[name] = value
+			}
+			for name, value := range runArgs {
+				args[name] = value
+			}
+		case strings.Contains(createdBy, "#(nop)"):
+			// An ARG instruction, recorded as
+			// "/bin/sh -c #(nop)  ARG A=1"
+			fields := strings.Fields(createdBy[strings.Index(createdBy, "#(nop)")+len("#(nop)"):])
+			if len(fields) < 2 || fields[0] != "ARG" {
+				continue
+			}
+			for _, arg := range fields[1:] {
+				if name, value, ok := splitBuildArg(arg); ok {
+					defaults[name] = value
+				} else if arg != "" {
+					defaults[arg] = ""
+				}
+			}
+		}
+	}
+	for name, value := range defaults {
+		if _, ok := args[name]; !ok {
+			args[name] = value
+		}
+	}
+	return args
+}
+
+// splitBuildArg splits a NAME=value build argument
+func splitBuildArg(arg string) (string, string, bool) {
+	parts := strings.SplitN(arg, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
@@ -0,0 +1,28 @@
+package image
+
+import (
+	"testing"
+
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBuildArgs(t *testing.T) {
+	history := []ociv1.History{
+		{CreatedBy: "/bin/sh -c #(nop) ADD file:abc in / "},
+		{CreatedBy: "/bin/sh -c #(nop)  ARG VERSION=1.0"},
+		{CreatedBy: "/bin/sh -c #(nop)  ARG TARGET"},
+		{CreatedBy: "/bin/sh -c #(nop)  ARG UNUSED=default"},
+		{CreatedBy: "|2 TARGET=release VERSION=2.0 /bin/sh -c make $TARGET"},
+		{CreatedBy: "/bin/sh -c echo |1 NOT=anarg"},
+		{CreatedBy: "|3 BROKEN=1 /bin/sh -c true"},
+		{CreatedBy: "/bin/sh -c #(nop)  ENV PATH=/usr/bin"},
+	}
+	assert.Equal(t, map[string]string{
+		"VERSION": "2.0",
+		"TARGET":  "release",
+		"UNUSED":  "default",
+	}, parseBuildArgs(history))
+
+	assert.Empty(t, parseBuildArgs(nil))
+}
@@ -0,0 +1,269 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/containers/image/manifest"
+	is "github.com/containers/image/storage"
+	"github.com/containers/storage"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+// ImageConfigPatch describes changes to the configuration of an image.  Fields
+// left at their zero value leave the configuration unchanged.  Additions are
+// applied after removals.
+type ImageConfigPatch struct {
+	// AddEnv are environment variables in KEY=value form to set, replacing
+	// any existing value of KEY
+	AddEnv []string
+	// RemoveEnv are the names of environment variables to remove
+	RemoveEnv []string
+	// SetLabels are labels to set, replacing any existing values
+	SetLabels map[string]string
+	// UnsetLabels are the names of labels to remove
+	UnsetLabels []string
+	// Entrypoint replaces the entrypoint if it is not nil.  An empty,
+	// non-nil slice clears it.
+	Entrypoint []string
+	// Cmd replaces the command if it is not nil.  An empty, non-nil slice
+	// clears it.
+	Cmd []string
+	// User replaces the user if it is not nil
+	User *string
+	// WorkDir replaces the working directory if it is not nil
+	WorkDir *string
+	// AddPorts are ports to expose, in port[/protocol] form.  The protocol
+	// defaults to tcp.
+	AddPorts []string
+	// RemovePorts are exposed ports to remove, in the same form as AddPorts
+	RemovePorts []string
+}
+
+// Validate checks that the patch is well formed
+func (p *ImageConfigPatch) Validate() error {
+	for _, env := range p.AddEnv {
+		if !strings.Contains(env, "=") || strings.HasPrefix(env, "=") {
+			return errors.Errorf("environment variable %q must be in KEY=value form", env)
+		}
+	}
+	for _, name := range p.RemoveEnv {
+		if name == "" || strings.Contains(name, "=") {
+			return errors.Errorf("invalid environment variable name %q", name)
+		}
+	}
+	for name := range p.SetLabels {
+		if name == "" {
+			return errors.Errorf("label names must not be empty")
+		}
+	}
+	for _, name := range p.UnsetLabels {
+		if name == "" {
+			return errors.Errorf("label names must not be empty")
+		}
+	}
+	for _, port := range append(append([]string{}, p.AddPorts...), p.RemovePorts...) {
+		if _, err := normalizePort(port); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizePort returns a port in port/protocol form
+func normalizePort(port string) (string, error) {
+	number, protocol := port, "tcp"
+	if n := strings.Index(port, "/"); n >= 0 {
+		number, protocol = port[:n], strings.ToLower(port[n+1:])
+	}
+	if p, err := strconv.ParseUint(number, 10, 16); err != nil || p == 0 {
+		return "", errors.Errorf("invalid port %q", port)
+	}
+	switch protocol {
+	case "tcp", "udp", "sctp":
+	default:
+		return "", errors.Errorf("invalid protocol %q for port %q", protocol, port)
+	}
+	return fmt.Sprintf("%s/%s", number, protocol), nil
+}
+
+// PatchConfig creates a new image named newName which has the same layers as
+// the image and its configuration with the patch applied.  The patch is
+// validated before anything is changed.  As with ScrubHistory, the new image
+// has a different ID and signatures of the image do not carry over.
+func (i *Image) PatchConfig(ctx context.Context, newName string, patch ImageConfigPatch) (*Image, error) {
+	if err := patch.Validate(); err != nil {
+		return nil, err
+	}
+	return i.rewriteConfig(ctx, newName, "patch configuration of", func(config map[string]interface{}) error {
+		// OCI and docker configurations name the fields of the
+		// runtime configuration the same way
+		runConfig, ok := config["config"].(map[string]interface{})
+		if !ok {
+			runConfig = make(map[string]interface{})
+			config["config"] = runConfig
+		}
+		patchEnv(runConfig, patch.AddEnv, patch.RemoveEnv)
+		patchLabels(runConfig, patch.SetLabels, patch.UnsetLabels)
+		patchPorts(runConfig, patch.AddPorts, patch.RemovePorts)
+		if patch.Entrypoint != nil {
+			runConfig["Entrypoint"] = patch.Entrypoint
+		}
+		if patch.Cmd != nil {
+			runConfig["Cmd"] = patch.Cmd
+		}
+		if patch.User != nil {
+			runConfig["User"] = *patch.User
+		}
+		if patch.WorkDir != nil {
+			runConfig["WorkingDir"] = *patch.WorkDir
+		}
+		return nil
+	})
+}
+
+// patchEnv removes and then adds environment variables, keeping the order of
+// those which remain
+func patchEnv(runConfig map[string]interface{}, add, remove []string) {
+	if len(add) == 0 && len(remove) == 0 {
+		return
+	}
+	drop := make(map[string]bool)
+	for _, name := range remove {
+		drop[name] = true
+	}
+	for _, env := range add {
+		drop[strings.SplitN(env, "=", 2)[0]] = true
+	}
+	env := []string{}
+	if existing, ok := runConfig["Env"].([]interface{}); ok {
+		for _, e := range existing {
+			if e, ok := e.(string); ok && !drop[strings.SplitN(e, "=", 2)[0]] {
+				env = append(env, e)
+			}
+		}
+	}
+	runConfig["Env"] = append(env, add...)
+}
+
+// patchLabels removes and then sets labels
+func patchLabels(runConfig map[string]interface{}, set map[string]string, unset []string) {
+	if len(set) == 0 && len(unset) == 0 {
+		return
+	}
+	labels, ok := runConfig["Labels"].(map[string]interface{})
+	if !ok {
+		labels = make(map[string]interface{})
+	}
+	for _, name := range unset {
+		delete(labels, name)
+	}
+	for name, value := range set {
+		labels[name] = value
+	}
+	runConfig["Labels"] = labels
+}
+
+// patchPorts removes and then adds exposed ports
+func patchPorts(runConfig map[string]interface{}, add, remove []string) {
+	if len(add) == 0 && len(remove) == 0 {
+		return
+	}
+	ports, ok := runConfig["ExposedPorts"].(map[string]interface{})
+	if !ok {
+		ports = make(map[string]interface{})
+	}
+	for _, port := range remove {
+		port, _ = normalizePort(port)
+		delete(ports, port)
+	}
+	for _, port := range add {
+		port, _ = normalizePort(port)
+		ports[port] = struct{}{}
+	}
+	runConfig["ExposedPorts"] = ports
+}
+
+// rewriteConfig creates a new image named newName which has the same layers as
+// the image and its configuration as changed by edit.  The configuration is
+// edited as generic JSON so that fields we do not know about survive.  The
+// action describes the change in error messages.
+func (i *Image) rewriteConfig(ctx context.Context, newName, action string, edit func(config map[string]interface{}) error) (*Image, error) {
+	manifestBlob, manifestType, err := i.Manifest(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get manifest of image %s", i.ID())
+	}
+	if manifestType != ociv1.MediaTypeImageManifest && manifestType != manifest.DockerV2Schema2MediaType {
+		return nil, errors.Errorf("unable to %s image %s: unsupported manifest type %q", action, i.ID(), manifestType)
+	}
+	imgRef, err := i.toImageRef(ctx)
+	if err != nil {
+		return nil, err
+	}
+	configBlob, err := imgRef.ConfigBlob(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get configuration of image %s", i.ID())
+	}
+
+	var config map[string]interface{}
+	if err := json.Unmarshal(configBlob, &config); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse configuration of image %s", i.ID())
+	}
+	if err := edit(config); err != nil {
+		return nil, errors.Wrapf(err, "unable to %s image %s", action, i.ID())
+	}
+	newConfig, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	configDigest := digest.FromBytes(newConfig)
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(manifestBlob, &m); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse manifest of image %s", i.ID())
+	}
+	configDescriptor, ok := m["config"].(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("manifest of image %s has no config descriptor", i.ID())
+	}
+	configDescriptor["digest"] = configDigest.String()
+	configDescriptor["size"] = len(newConfig)
+	newManifest, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	manifestDigest, err := manifest.Digest(newManifest)
+	if err != nil {
+		return nil, err
+	}
+
+	dest, err := is.Transport.ParseStoreReference(i.imageruntime.store, newName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting image reference for %q", newName)
+	}
+	if dest.DockerReference() == nil {
+		return nil, errors.Errorf("%q is not a valid image name", newName)
+	}
+	// Images stored by containers/image are identified by their config digest
+	img, err := i.imageruntime.store.CreateImage(configDigest.Hex(), []string{dest.DockerReference().String()}, i.TopLayer(), "", &storage.ImageOptions{
+		CreationDate: i.Created(),
+		Digest:       manifestDigest,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create image %s", newName)
+	}
+	if err := i.imageruntime.store.SetImageBigData(img.ID, configDigest.String(), newConfig); err != nil {
+		i.imageruntime.store.DeleteImage(img.ID, true)
+		return nil, errors.Wrapf(err, "unable to store configuration of image %s", newName)
+	}
+	if err := i.imageruntime.store.SetImageBigData(img.ID, "manifest", newManifest); err != nil {
+		i.imageruntime.store.DeleteImage(img.ID, true)
+		return nil, errors.Wrapf(err, "unable to store manifest of image %s", newName)
+	}
+	return i.imageruntime.NewFromLocal(img.ID)
+}
@@ -0,0 +1,97 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/containers/storage"
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+)
+
+// createConfiguredImage creates an image without layers in the runtime's
+// storage with the given runtime configuration
+func createConfiguredImage(t *testing.T, ir *Runtime, name, runConfig string) *Image {
+	config := []byte(fmt.Sprintf(`{"architecture":"amd64","os":"linux","config":%s,"rootfs":{"type":"layers","diff_ids":[]}}`, runConfig))
+	configDigest := digest.FromBytes(config)
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{"mediaType":"application/vnd.docker.container.image.v1+json","size":%d,"digest":%q},"layers":[]}`, len(config), configDigest))
+	img, err := ir.store.CreateImage(configDigest.Hex(), []string{name}, "", "", &storage.ImageOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, ir.store.SetImageBigData(img.ID, "manifest", manifest))
+	assert.NoError(t, ir.store.SetImageBigData(img.ID, configDigest.String(), config))
+	newImage, err := ir.NewFromLocal(img.ID)
+	assert.NoError(t, err)
+	return newImage
+}
+
+func TestImage_PatchConfig(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:   workdir,
+		GraphRoot: workdir,
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	original := createConfiguredImage(t, ir, "docker.io/library/original:latest", `{"Env":["PATH=/usr/bin","DEBUG=1","LANG=C"],"Labels":{"a":"1","b":"2"},"ExposedPorts":{"80/tcp":{},"53/udp":{}},"Entrypoint":["/entrypoint"],"Cmd":["run"],"User":"app","WorkingDir":"/app"}`)
+
+	// Additions
+	user := "root"
+	added, err := original.PatchConfig(ctx, "added", ImageConfigPatch{
+		AddEnv:    []string{"LANG=en_US.UTF-8", "NEW=yes"},
+		SetLabels: map[string]string{"b": "3", "c": "4"},
+		AddPorts:  []string{"443", "8080/udp"},
+		User:      &user,
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, original.ID(), added.ID())
+	config, err := added.Config(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"PATH=/usr/bin", "DEBUG=1", "LANG=en_US.UTF-8", "NEW=yes"}, config.Env)
+	assert.Equal(t, map[string]string{"a": "1", "b": "3", "c": "4"}, config.Labels)
+	assert.Len(t, config.ExposedPorts, 4)
+	assert.Contains(t, config.ExposedPorts, "443/tcp")
+	assert.Contains(t, config.ExposedPorts, "8080/udp")
+	assert.Equal(t, "root", config.User)
+	// Fields the patch does not touch are unchanged
+	assert.Equal(t, []string{"/entrypoint"}, config.Entrypoint)
+	assert.Equal(t, []string{"run"}, config.Cmd)
+	assert.Equal(t, "/app", config.WorkingDir)
+
+	// Removals
+	removed, err := original.PatchConfig(ctx, "removed", ImageConfigPatch{
+		RemoveEnv:   []string{"DEBUG", "UNSET"},
+		UnsetLabels: []string{"a"},
+		RemovePorts: []string{"80"},
+		Entrypoint:  []string{},
+	})
+	assert.NoError(t, err)
+	config, err = removed.Config(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"PATH=/usr/bin", "LANG=C"}, config.Env)
+	assert.Equal(t, map[string]string{"b": "2"}, config.Labels)
+	assert.Len(t, config.ExposedPorts, 1)
+	assert.Contains(t, config.ExposedPorts, "53/udp")
+	assert.Empty(t, config.Entrypoint)
+	assert.Equal(t, []string{"run"}, config.Cmd)
+	assert.Equal(t, "app", config.User)
+
+	// Invalid patches are rejected before any image is created
+	for _, patch := range []ImageConfigPatch{
+		{AddEnv: []string{"NOVALUE"}},
+		{RemoveEnv: []string{"A=B"}},
+		{SetLabels: map[string]string{"": "x"}},
+		{AddPorts: []string{"http"}},
+		{RemovePorts: []string{"80/icmp"}},
+	} {
+		_, err := original.PatchConfig(ctx, "invalid", patch)
+		assert.Error(t, err)
+	}
+	assert.False(t, ir.ImagesExist([]string{"invalid"})["invalid"])
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
@@ -0,0 +1,149 @@
+package image
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/containers/image/docker/reference"
+	"github.com/containers/image/transports/alltransports"
+	"github.com/pkg/errors"
+)
+
+// ExportDeterministic saves the image to a docker-archive or oci-archive
+// destination, as PushImage does, and then normalizes the archive with
+// NormalizeArchive, so that exporting the same image twice produces identical
+// bytes.  The archive is first written to a temporary file next to the
+// destination, or in the system's temporary directory if the destination is
+// not a regular file, such as /dev/stdout.
+func (i *Image) ExportDeterministic(ctx context.Context, destination, manifestMIMEType, signaturePolicyPath string, writer io.Writer, additionalDockerArchiveTags []reference.NamedTagged) error {
+	destRef, err := alltransports.ParseImageName(destination)
+	if err != nil {
+		return errors.Wrapf(err, "error getting destination imageReference for %q", destination)
+	}
+	transport := destRef.Transport().Name()
+	if transport != DockerArchive && transport != OCIArchive {
+		return errors.Errorf("deterministic export is only supported to %s and %s, not %s", DockerArchive, OCIArchive, transport)
+	}
+	// The archive transports take a path, optionally followed by a
+	// reference
+	withinTransport := strings.TrimPrefix(destination, transport+":")
+	parts := strings.SplitN(withinTransport, ":", 2)
+	path := parts[0]
+
+	tmpDir := filepath.Dir(path)
+	if info, err := os.Stat(path); err == nil && !info.Mode().IsRegular() {
+		tmpDir = ""
+	}
+	tmp, err := ioutil.TempFile(tmpDir, ".export-")
+	if err != nil {
+		return errors.Wrapf(err, "unable to create temporary file for export")
+	}
+	tmp.Close()
+	// The archive transports refuse to overwrite an existing file
+	os.Remove(tmp.Name())
+	defer os.Remove(tmp.Name())
+
+	tmpDestination := transport + ":" + tmp.Name()
+	if len(parts) > 1 {
+		tmpDestination += ":" + parts[1]
+	}
+	if err := i.PushImage(ctx, tmpDestination, manifestMIMEType, "", signaturePolicyPath, writer, false, SigningOptions{}, &DockerRegistryOptions{}, false, additionalDockerArchiveTags); err != nil {
+		return err
+	}
+
+	output, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "unable to open %s", path)
+	}
+	if err := NormalizeArchive(tmp.Name(), output); err != nil {
+		output.Close()
+		return errors.Wrapf(err, "unable to write %s", path)
+	}
+	return output.Close()
+}
+
+// normalizedEntry is an entry of an archive being normalized, and where its
+// contents are in the archive
+type normalizedEntry struct {
+	header *tar.Header
+	offset int64
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
+
+// NormalizeArchive writes a copy of the tar archive at archivePath to w with
+// the metadata which varies between exports of the same image normalized:
+//
+// - entries are sorted by name
+// - modification, access and change times are set to the Unix epoch
+// - user and group IDs are set to 0, and user and group names are removed
+// - extended (PAX) attributes are removed
+//
+// Entry names, types, permissions, link targets and contents are kept.
+func NormalizeArchive(archivePath string, w io.Writer) error {
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	// Index the entries first, so that they can be copied in order
+	// without holding their contents in memory
+	counter := &countingReader{reader: archive}
+	tr := tar.NewReader(counter)
+	var entries []normalizedEntry
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrapf(err, "unable to read archive %s", archivePath)
+		}
+		entries = append(entries, normalizedEntry{header: header, offset: counter.count})
+	}
+	sort.SliceStable(entries, func(a, b int) bool {
+		return entries[a].header.Name < entries[b].header.Name
+	})
+
+	epoch := time.Unix(0, 0)
+	tw := tar.NewWriter(w)
+	for _, entry := range entries {
+		header := &tar.Header{
+			Typeflag: entry.header.Typeflag,
+			Name:     entry.header.Name,
+			Linkname: entry.header.Linkname,
+			Size:     entry.header.Size,
+			Mode:     entry.header.Mode,
+			ModTime:  epoch,
+			Devmajor: entry.header.Devmajor,
+			Devminor: entry.header.Devminor,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if header.Size > 0 {
+			if _, err := io.Copy(tw, io.NewSectionReader(archive, entry.offset, header.Size)); err != nil {
+				return err
+			}
+		}
+	}
+	return tw.Close()
+}
@@ -0,0 +1,128 @@
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/containers/storage"
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeArchive writes a tar archive of the given files, in order, with the
+// given modification time and owner
+func writeArchive(t *testing.T, path string, names []string, modTime time.Time, uid int) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range names {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(name)),
+			Typeflag: tar.TypeReg,
+			ModTime:  modTime,
+			Uid:      uid,
+			Uname:    "someone",
+		}))
+		_, err := tw.Write([]byte(name))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, ioutil.WriteFile(path, buf.Bytes(), 0644))
+}
+
+func TestNormalizeArchive(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	defer os.RemoveAll(workdir)
+
+	first := filepath.Join(workdir, "first.tar")
+	second := filepath.Join(workdir, "second.tar")
+	writeArchive(t, first, []string{"manifest.json", "blobs/b", "blobs/a"}, time.Now(), 1000)
+	writeArchive(t, second, []string{"blobs/a", "manifest.json", "blobs/b"}, time.Now().Add(time.Hour), 0)
+
+	var firstNormalized, secondNormalized bytes.Buffer
+	assert.NoError(t, NormalizeArchive(first, &firstNormalized))
+	assert.NoError(t, NormalizeArchive(second, &secondNormalized))
+	assert.Equal(t, firstNormalized.Bytes(), secondNormalized.Bytes())
+
+	tr := tar.NewReader(&firstNormalized)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, header.Name)
+		assert.Equal(t, int64(0), header.ModTime.Unix())
+		assert.Equal(t, 0, header.Uid)
+		assert.Empty(t, header.Uname)
+		content, err := ioutil.ReadAll(tr)
+		assert.NoError(t, err)
+		assert.Equal(t, header.Name, string(content))
+	}
+	assert.Equal(t, []string{"blobs/a", "blobs/b", "manifest.json"}, names)
+}
+
+func TestImage_ExportDeterministic(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:         workdir,
+		GraphRoot:       workdir,
+		GraphDriverName: "vfs",
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	layer, _, err := ir.store.PutLayer("", "", nil, "", false, nil, bytes.NewReader(layerTar(t, "file", "content")))
+	assert.NoError(t, err)
+	config := []byte(fmt.Sprintf(`{"architecture":"amd64","os":"linux","config":{},"rootfs":{"type":"layers","diff_ids":[%q]}}`, layer.UncompressedDigest))
+	configDigest := digest.FromBytes(config)
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{"mediaType":"application/vnd.docker.container.image.v1+json","size":%d,"digest":%q},"layers":[{"mediaType":"application/vnd.docker.image.rootfs.diff.tar","size":%d,"digest":%q}]}`, len(config), configDigest, layer.UncompressedSize, layer.UncompressedDigest))
+	img, err := ir.store.CreateImage(configDigest.Hex(), []string{"docker.io/library/exported:latest"}, layer.ID, "", &storage.ImageOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, ir.store.SetImageBigData(img.ID, "manifest", manifest))
+	assert.NoError(t, ir.store.SetImageBigData(img.ID, configDigest.String(), config))
+	exported, err := ir.NewFromLocal("exported")
+	assert.NoError(t, err)
+
+	policy := filepath.Join(workdir, "policy.json")
+	assert.NoError(t, ioutil.WriteFile(policy, []byte(`{"default":[{"type":"insecureAcceptAnything"}]}`), 0644))
+	registries := filepath.Join(workdir, "registries.conf")
+	assert.NoError(t, ioutil.WriteFile(registries, []byte("[registries.search]\nregistries = []\n"), 0644))
+	defer os.Setenv("REGISTRIES_CONFIG_PATH", os.Getenv("REGISTRIES_CONFIG_PATH"))
+	os.Setenv("REGISTRIES_CONFIG_PATH", registries)
+
+	for _, transport := range []string{DockerArchive, OCIArchive} {
+		var archives [][]byte
+		for n := 0; n < 2; n++ {
+			path := filepath.Join(workdir, fmt.Sprintf("%s-%d.tar", transport, n))
+			assert.NoError(t, exported.ExportDeterministic(ctx, transport+":"+path+":exported:latest", "", policy, nil, nil))
+			archive, err := ioutil.ReadFile(path)
+			assert.NoError(t, err)
+			archives = append(archives, archive)
+			if n == 0 {
+				// Let the time move on, so that timestamps would
+				// differ
+				time.Sleep(time.Second)
+			}
+		}
+		assert.NotEmpty(t, archives[0])
+		assert.Equal(t, archives[0], archives[1], transport)
+	}
+
+	// Only archives can be made deterministic
+	assert.Error(t, exported.ExportDeterministic(ctx, "dir:"+filepath.Join(workdir, "dir"), "", policy, nil, nil))
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
@@ -0,0 +1,80 @@
+package image
+
+import (
+	"strings"
+
+	"github.com/docker/docker/builder/dockerfile/command"
+	"github.com/openshift/imagebuilder"
+	"github.com/pkg/errors"
+)
+
+// DockerfileArg describes a build argument declared by an ARG instruction
+type DockerfileArg struct {
+	// Name is the name of the argument
+	Name string
+	// Default is the argument's default value, or empty if it has none
+	Default string
+	// HasDefault is true if the ARG instruction supplied a default value
+	HasDefault bool
+	// Global is true if the argument is declared before the first FROM
+	Global bool
+	// Stage is the index of the build stage declaring the argument, or -1
+	// for global arguments
+	Stage int
+	// StageName is the name given to the declaring stage with FROM ... AS,
+	// if any
+	StageName string
+}
+
+// GetDockerfileArgs parses the content of a Dockerfile or Containerfile and
+// returns the build arguments it declares, in the order they are declared.
+// Nothing is built.  Default values are expanded using the arguments declared
+// earlier in the same scope, as they would be during a build.
+func GetDockerfileArgs(content string) ([]DockerfileArg, error) {
+	node, err := imagebuilder.ParseDockerfile(strings.NewReader(content))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing Dockerfile")
+	}
+
+	var (
+		args      []DockerfileArg
+		stage     = -1
+		stageName string
+		env       []string
+	)
+	for _, child := range node.Children {
+		switch child.Value {
+		case command.From:
+			stage++
+			stageName = ""
+			// Arguments do not carry over into stages unless redeclared
+			env = nil
+			if n := child.Next; n != nil && n.Next != nil && strings.EqualFold(n.Next.Value, "as") && n.Next.Next != nil {
+				stageName = n.Next.Next.Value
+			}
+		case command.Arg:
+			if child.Next == nil {
+				return nil, errors.Errorf("ARG requires exactly one argument definition on line %d", child.StartLine)
+			}
+			word, err := imagebuilder.ProcessWord(child.Next.Value, env)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error processing ARG on line %d", child.StartLine)
+			}
+			arg := DockerfileArg{
+				Name:      word,
+				Global:    stage < 0,
+				Stage:     stage,
+				StageName: stageName,
+			}
+			if strings.Contains(word, "=") {
+				parts := strings.SplitN(word, "=", 2)
+				arg.Name = parts[0]
+				arg.Default = parts[1]
+				arg.HasDefault = true
+			}
+			env = append(env, arg.Name+"="+arg.Default)
+			args = append(args, arg)
+		}
+	}
+	return args, nil
+}
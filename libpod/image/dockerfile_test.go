@@ -0,0 +1,30 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDockerfileArgs(t *testing.T) {
+	content := `ARG BASE=fedora
+ARG VERSION
+FROM $BASE:28 AS builder
+ARG VERSION
+ARG PREFIX="/usr/local"
+ARG BINDIR=${PREFIX}/bin
+RUN make install
+FROM $BASE
+ARG RELEASE=1
+`
+	args, err := GetDockerfileArgs(content)
+	assert.NoError(t, err)
+	assert.Equal(t, []DockerfileArg{
+		{Name: "BASE", Default: "fedora", HasDefault: true, Global: true, Stage: -1},
+		{Name: "VERSION", Global: true, Stage: -1},
+		{Name: "VERSION", Stage: 0, StageName: "builder"},
+		{Name: "PREFIX", Default: "/usr/local", HasDefault: true, Stage: 0, StageName: "builder"},
+		{Name: "BINDIR", Default: "/usr/local/bin", HasDefault: true, Stage: 0, StageName: "builder"},
+		{Name: "RELEASE", Default: "1", HasDefault: true, Stage: 1},
+	}, args)
+}
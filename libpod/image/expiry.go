@@ -0,0 +1,93 @@
+package image
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// expiryMetadataKey is the local metadata key holding the time an image
+// expires at, in seconds since the Unix epoch
+const expiryMetadataKey = "libpod.expires-at"
+
+// SetImageExpiry sets the time the named image expires at, in seconds since
+// the Unix epoch, after which PruneExpiredImages removes it.  An expiry of 0
+// removes the image's expiry.  The expiry is stored as local metadata, so
+// setting it does not change the image's ID.
+func (ir *Runtime) SetImageExpiry(name string, expiresAt int64) error {
+	if expiresAt < 0 {
+		return errors.Errorf("invalid expiry %d, must not be negative", expiresAt)
+	}
+	img, err := ir.NewFromLocal(name)
+	if err != nil {
+		return err
+	}
+	value := ""
+	if expiresAt > 0 {
+		value = strconv.FormatInt(expiresAt, 10)
+	}
+	return img.SetLocalMetadata(map[string]string{expiryMetadataKey: value})
+}
+
+// Expiry returns the time the image expires at, in seconds since the Unix
+// epoch, or 0 if it does not expire
+func (i *Image) Expiry() (int64, error) {
+	metadata, err := i.LocalMetadata()
+	if err != nil {
+		return 0, err
+	}
+	value, ok := metadata[expiryMetadataKey]
+	if !ok {
+		return 0, nil
+	}
+	expiresAt, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid expiry %q of image %s", value, i.ID())
+	}
+	return expiresAt, nil
+}
+
+// PruneExpiredImages removes the images whose expiry, set with
+// SetImageExpiry, has passed.  Images used by containers are kept, and are
+// removed by a later prune once they are no longer used.
+func (ir *Runtime) PruneExpiredImages(ctx context.Context) (*PruneReport, error) {
+	return ir.pruneExpiredImages(ctx, time.Now())
+}
+
+// pruneExpiredImages removes the images which have expired at the given time
+func (ir *Runtime) pruneExpiredImages(ctx context.Context, now time.Time) (*PruneReport, error) {
+	images, err := ir.GetImages()
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get list of images")
+	}
+
+	report := &PruneReport{}
+	for _, img := range images {
+		expiresAt, err := img.Expiry()
+		if err != nil {
+			return report, err
+		}
+		if expiresAt == 0 || expiresAt > now.Unix() {
+			continue
+		}
+		containers, err := img.Containers()
+		if err != nil {
+			return report, errors.Wrapf(err, "unable to get containers of image %s", img.ID())
+		}
+		if len(containers) > 0 {
+			continue
+		}
+		// Determine the size before the image is gone
+		size, _ := img.Size(ctx)
+		if err := img.Remove(false); err != nil {
+			return report, errors.Wrapf(err, "unable to remove image %s", img.ID())
+		}
+		report.Removed = append(report.Removed, img.ID())
+		if size != nil {
+			report.Size += *size
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,79 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/containers/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuntime_PruneExpiredImages(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:         workdir,
+		GraphRoot:       workdir,
+		GraphDriverName: "vfs",
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	// The image used by a container needs a layer for the container
+	layer, _, err := ir.store.PutLayer("", "", nil, "", false, nil, bytes.NewReader(layerTar(t, "file", "content")))
+	assert.NoError(t, err)
+	var ids []string
+	for _, name := range []string{"expired", "valid", "forever", "used"} {
+		topLayer := ""
+		if name == "used" {
+			topLayer = layer.ID
+		}
+		img, err := ir.store.CreateImage("", []string{"docker.io/library/" + name + ":latest"}, topLayer, "", &storage.ImageOptions{})
+		assert.NoError(t, err)
+		ids = append(ids, img.ID)
+	}
+	now := time.Now()
+	assert.NoError(t, ir.SetImageExpiry("expired", now.Add(-time.Hour).Unix()))
+	assert.NoError(t, ir.SetImageExpiry("valid", now.Add(time.Hour).Unix()))
+	assert.NoError(t, ir.SetImageExpiry("used", now.Add(-time.Hour).Unix()))
+	_, err = ir.store.CreateContainer("", nil, ids[3], "", "", nil)
+	assert.NoError(t, err)
+
+	// The expiry is local metadata, which leaves the ID unchanged
+	expired, err := ir.NewFromLocal("expired")
+	assert.NoError(t, err)
+	assert.Equal(t, ids[0], expired.ID())
+	expiresAt, err := expired.Expiry()
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(-time.Hour).Unix(), expiresAt)
+	forever, err := ir.NewFromLocal("forever")
+	assert.NoError(t, err)
+	expiresAt, err = forever.Expiry()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), expiresAt)
+
+	report, err := ir.PruneExpiredImages(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{ids[0]}, report.Removed)
+	assert.Equal(t, map[string]bool{
+		"expired": false,
+		"valid":   true,
+		"forever": true,
+		"used":    true,
+	}, ir.ImagesExist([]string{"expired", "valid", "forever", "used"}))
+
+	// Once its time has come, the valid image is pruned too, unless its
+	// expiry was removed
+	report, err = ir.pruneExpiredImages(ctx, now.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{ids[1]}, report.Removed)
+	assert.NoError(t, ir.SetImageExpiry("used", 0))
+	assert.Error(t, ir.SetImageExpiry("forever", -1))
+	assert.Error(t, ir.SetImageExpiry("missing", now.Unix()))
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
@@ -0,0 +1,195 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/containers/image/manifest"
+	is "github.com/containers/image/storage"
+	"github.com/containers/image/types"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+// dirTransportVersion is the content of the version file the dir transport
+// writes to, and expects in, image directories
+const dirTransportVersion = "Directory Transport Version: 1.1\n"
+
+// ExportLayers writes the image to the destination directory in the layout
+// of the dir transport, skipping the layers whose digests are in
+// excludeDigests, which a previous export to the same directory is expected
+// to have written.  Layers are exported uncompressed and identified by their
+// uncompressed digests, the diff IDs in the image's configuration, so that
+// images sharing base layers share layer files.  Each excluded layer of the
+// image must already be present in the directory with the right content, so
+// that the directory always holds a complete image which can be read back
+// with the dir transport; excluded digests which are not layers of the image
+// are ignored.  The manifest and configuration are always written.  The
+// digests of the layers written are returned.
+func (i *Image) ExportLayers(ctx context.Context, excludeDigests []string, destination string) ([]digest.Digest, error) {
+	exclude := make(map[digest.Digest]bool)
+	for _, d := range excludeDigests {
+		parsed, err := digest.Parse(d)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid layer digest %q", d)
+		}
+		exclude[parsed] = true
+	}
+
+	manifestBlob, manifestType, err := i.Manifest(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get manifest of image %s", i.ID())
+	}
+	if manifestType != ociv1.MediaTypeImageManifest && manifestType != manifest.DockerV2Schema2MediaType {
+		return nil, errors.Errorf("unable to export layers of image %s: unsupported manifest type %q", i.ID(), manifestType)
+	}
+	storeRef, err := is.Transport.ParseStoreReference(i.imageruntime.store, i.ID())
+	if err != nil {
+		return nil, err
+	}
+	src, err := storeRef.NewImageSource(ctx, &types.SystemContext{})
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	layerInfos, err := src.LayerInfosForCopy(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get layers of image %s", i.ID())
+	}
+	imgRef, err := i.toImageRef(ctx)
+	if err != nil {
+		return nil, err
+	}
+	configBlob, err := imgRef.ConfigBlob(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get configuration of image %s", i.ID())
+	}
+
+	if err := prepareImageDir(destination); err != nil {
+		return nil, err
+	}
+
+	// Check the excluded layers before writing anything else, so that an
+	// incomplete backup is not mistaken for a complete one
+	for _, info := range layerInfos {
+		if exclude[info.Digest] {
+			if err := verifyBlobFile(filepath.Join(destination, info.Digest.Hex()), info.Digest); err != nil {
+				return nil, errors.Wrapf(err, "excluded layer %s is not present in %s", info.Digest, destination)
+			}
+		}
+	}
+
+	var written []digest.Digest
+	for _, info := range layerInfos {
+		if exclude[info.Digest] {
+			continue
+		}
+		rc, _, err := src.GetBlob(ctx, info)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read layer %s of image %s", info.Digest, i.ID())
+		}
+		err = writeBlobFile(filepath.Join(destination, info.Digest.Hex()), info.Digest, rc)
+		rc.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to write layer %s", info.Digest)
+		}
+		written = append(written, info.Digest)
+	}
+
+	configDigest := digest.FromBytes(configBlob)
+	if err := ioutil.WriteFile(filepath.Join(destination, configDigest.Hex()), configBlob, 0644); err != nil {
+		return nil, errors.Wrapf(err, "unable to write configuration of image %s", i.ID())
+	}
+
+	// The layers are exported uncompressed, so the manifest must describe
+	// them as they were written rather than as they were pulled
+	var m map[string]interface{}
+	if err := json.Unmarshal(manifestBlob, &m); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse manifest of image %s", i.ID())
+	}
+	var layers []interface{}
+	for _, info := range layerInfos {
+		layers = append(layers, map[string]interface{}{
+			"mediaType": info.MediaType,
+			"size":      info.Size,
+			"digest":    info.Digest.String(),
+		})
+	}
+	m["layers"] = layers
+	newManifest, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(destination, "manifest.json"), newManifest, 0644); err != nil {
+		return nil, errors.Wrapf(err, "unable to write manifest of image %s", i.ID())
+	}
+	return written, nil
+}
+
+// prepareImageDir creates a directory for a dir transport image, or checks
+// that an existing directory holds one, so that other data is not
+// overwritten
+func prepareImageDir(dir string) error {
+	contents, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(contents) > 0 {
+		version, err := ioutil.ReadFile(filepath.Join(dir, "version"))
+		if err != nil || string(version) != dirTransportVersion {
+			return errors.Errorf("%s is not an image directory, refusing to write to it", dir)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrapf(err, "unable to create directory %s", dir)
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "version"), []byte(dirTransportVersion), 0644)
+}
+
+// verifyBlobFile checks that a file exists and has the given digest
+func verifyBlobFile(path string, blobDigest digest.Digest) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	actual, err := blobDigest.Algorithm().FromReader(f)
+	if err != nil {
+		return err
+	}
+	if actual != blobDigest {
+		return errors.Errorf("%s has digest %s", path, actual)
+	}
+	return nil
+}
+
+// writeBlobFile writes a blob to a file, checking its digest, without leaving
+// a partially written file behind on failure
+func writeBlobFile(path string, blobDigest digest.Digest, r io.Reader) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	verifier := blobDigest.Verifier()
+	if _, err := io.Copy(io.MultiWriter(tmp, verifier), r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if !verifier.Verified() {
+		return errors.Errorf("content does not match digest %s", blobDigest)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,100 @@
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containers/storage"
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+)
+
+// layerTar returns a tar archive holding a single file
+func layerTar(t *testing.T, name, content string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(content)),
+		Typeflag: tar.TypeReg,
+	}))
+	_, err := tw.Write([]byte(content))
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func TestImage_ExportLayers(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:         workdir,
+		GraphRoot:       workdir,
+		GraphDriverName: "vfs",
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	base, _, err := ir.store.PutLayer("", "", nil, "", false, nil, bytes.NewReader(layerTar(t, "base", "base")))
+	assert.NoError(t, err)
+	top, _, err := ir.store.PutLayer("", base.ID, nil, "", false, nil, bytes.NewReader(layerTar(t, "top", "top")))
+	assert.NoError(t, err)
+	baseDigest, topDigest := base.UncompressedDigest, top.UncompressedDigest
+
+	config := []byte(fmt.Sprintf(`{"architecture":"amd64","os":"linux","config":{},"rootfs":{"type":"layers","diff_ids":[%q,%q]}}`, baseDigest, topDigest))
+	configDigest := digest.FromBytes(config)
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{"mediaType":"application/vnd.docker.container.image.v1+json","size":%d,"digest":%q},"layers":[{"mediaType":"application/vnd.docker.image.rootfs.diff.tar.gzip","size":1,"digest":%q},{"mediaType":"application/vnd.docker.image.rootfs.diff.tar.gzip","size":1,"digest":%q}]}`, len(config), configDigest, digest.FromString("base.gz"), digest.FromString("top.gz")))
+	img, err := ir.store.CreateImage(configDigest.Hex(), []string{"docker.io/library/layered:latest"}, top.ID, "", &storage.ImageOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, ir.store.SetImageBigData(img.ID, "manifest", manifest))
+	assert.NoError(t, ir.store.SetImageBigData(img.ID, configDigest.String(), config))
+	layered, err := ir.NewFromLocal("layered")
+	assert.NoError(t, err)
+
+	// Excluded layers must already be in the destination
+	empty := filepath.Join(workdir, "empty")
+	_, err = layered.ExportLayers(ctx, []string{baseDigest.String()}, empty)
+	assert.Error(t, err)
+
+	full := filepath.Join(workdir, "backup")
+	written, err := layered.ExportLayers(ctx, nil, full)
+	assert.NoError(t, err)
+	assert.Equal(t, []digest.Digest{baseDigest, topDigest}, written)
+	for _, name := range []string{"version", "manifest.json", configDigest.Hex(), baseDigest.Hex(), topDigest.Hex()} {
+		_, err := os.Stat(filepath.Join(full, name))
+		assert.NoError(t, err, name)
+	}
+	exported, err := ioutil.ReadFile(filepath.Join(full, "manifest.json"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(exported), baseDigest.String())
+	assert.NotContains(t, string(exported), digest.FromString("base.gz").String())
+
+	// An incremental export only writes the layers which are not excluded
+	assert.NoError(t, os.Remove(filepath.Join(full, topDigest.Hex())))
+	written, err = layered.ExportLayers(ctx, []string{baseDigest.String(), digest.FromString("unrelated").String()}, full)
+	assert.NoError(t, err)
+	assert.Equal(t, []digest.Digest{topDigest}, written)
+
+	// A corrupted excluded layer is detected
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(full, baseDigest.Hex()), []byte("corrupt"), 0644))
+	_, err = layered.ExportLayers(ctx, []string{baseDigest.String()}, full)
+	assert.Error(t, err)
+
+	// Directories which do not hold an image are not written to
+	other := filepath.Join(workdir, "other")
+	assert.NoError(t, os.MkdirAll(other, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(other, "important"), []byte("data"), 0644))
+	_, err = layered.ExportLayers(ctx, nil, other)
+	assert.Error(t, err)
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
@@ -5,9 +5,23 @@ import (
 	"strings"
 	"time"
 
+	"github.com/containers/image/manifest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
 	"github.com/projectatomic/libpod/pkg/inspect"
+	"github.com/projectatomic/libpod/pkg/util"
 )
 
+// ManifestMediaTypes are the media types an image's manifest can have
+var ManifestMediaTypes = []string{
+	ociv1.MediaTypeImageManifest,
+	ociv1.MediaTypeImageIndex,
+	manifest.DockerV2Schema1MediaType,
+	manifest.DockerV2Schema1SignedMediaType,
+	manifest.DockerV2Schema2MediaType,
+	manifest.DockerV2ListMediaType,
+}
+
 // ResultFilter is a mock function for image filtering
 type ResultFilter func(*Image) bool
 
@@ -60,6 +74,35 @@ func LabelFilter(ctx context.Context, labelfilter string) ResultFilter {
 	}
 }
 
+// LabelValueFilter allows you to filter images by the exact value of a label.
+// Unlike LabelFilter, the value may contain "=", and an empty value only
+// matches images which set the label to an empty value.
+func LabelValueFilter(ctx context.Context, key, value string) ResultFilter {
+	return func(i *Image) bool {
+		labels, err := i.Labels(ctx)
+		if err != nil {
+			return false
+		}
+		labelValue, ok := labels[key]
+		return ok && labelValue == value
+	}
+}
+
+// ManifestTypeFilter allows you to filter images by the media type of their
+// manifest.  The media type must be one of ManifestMediaTypes.
+func ManifestTypeFilter(ctx context.Context, mediaType string) (ResultFilter, error) {
+	if !util.StringInSlice(mediaType, ManifestMediaTypes) {
+		return nil, errors.Errorf("unknown manifest media type %q, must be one of %s", mediaType, strings.Join(ManifestMediaTypes, ", "))
+	}
+	return func(i *Image) bool {
+		_, manifestType, err := i.Manifest(ctx)
+		if err != nil {
+			return false
+		}
+		return manifestType == mediaType
+	}, nil
+}
+
 // OutputImageFilter allows you to filter by an a specific image name
 func OutputImageFilter(userImage *Image) ResultFilter {
 	return func(i *Image) bool {
@@ -67,6 +110,40 @@ func OutputImageFilter(userImage *Image) ResultFilter {
 	}
 }
 
+// CreateFilters returns the filter functions for a list of filters of the
+// form before=image, after=image, dangling or label=key[=value]
+func (ir *Runtime) CreateFilters(ctx context.Context, filters []string) ([]ResultFilter, error) {
+	var filterFuncs []ResultFilter
+	for _, filter := range filters {
+		splitFilter := strings.SplitN(filter, "=", 2)
+		value := ""
+		if len(splitFilter) > 1 {
+			value = splitFilter[1]
+		}
+		switch splitFilter[0] {
+		case "before":
+			before, err := ir.NewFromLocal(value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to find image %s in local stores", value)
+			}
+			filterFuncs = append(filterFuncs, CreatedBeforeFilter(before.Created()))
+		case "after":
+			after, err := ir.NewFromLocal(value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to find image %s in local stores", value)
+			}
+			filterFuncs = append(filterFuncs, CreatedAfterFilter(after.Created()))
+		case "dangling":
+			filterFuncs = append(filterFuncs, DanglingFilter())
+		case "label":
+			filterFuncs = append(filterFuncs, LabelFilter(ctx, value))
+		default:
+			return nil, errors.Errorf("invalid filter %s ", splitFilter[0])
+		}
+	}
+	return filterFuncs, nil
+}
+
 // FilterImages filters images using a set of predefined filter funcs
 func FilterImages(images []*Image, filters []ResultFilter) []*Image {
 	var filteredImages []*Image
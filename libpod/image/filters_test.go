@@ -0,0 +1,58 @@
+package image
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/containers/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuntime_GetImagesByLabel(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:   workdir,
+		GraphRoot: workdir,
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	first := createConfiguredImage(t, ir, "docker.io/library/app:1", `{"Labels":{"build-id":"1234","url":"https://ci.example.com/?build=1234"}}`)
+	second := createConfiguredImage(t, ir, "docker.io/library/app:1-debug", `{"Labels":{"build-id":"1234","debug":""}}`)
+	createConfiguredImage(t, ir, "docker.io/library/app:2", `{"Labels":{"build-id":"5678"}}`)
+	createConfiguredImage(t, ir, "docker.io/library/unlabeled:latest", `{}`)
+
+	// Labels are not unique, so all matching images are returned
+	images, err := ir.GetImagesByLabel(ctx, "build-id", "1234")
+	assert.NoError(t, err)
+	var ids []string
+	for _, img := range images {
+		ids = append(ids, img.ID())
+	}
+	expected := []string{first.ID(), second.ID()}
+	sort.Strings(expected)
+	sort.Strings(ids)
+	assert.Equal(t, expected, ids)
+
+	// Values are matched exactly, including ones containing "=" or empty
+	images, err = ir.GetImagesByLabel(ctx, "url", "https://ci.example.com/?build=1234")
+	assert.NoError(t, err)
+	assert.Len(t, images, 1)
+	images, err = ir.GetImagesByLabel(ctx, "debug", "")
+	assert.NoError(t, err)
+	assert.Len(t, images, 1)
+	assert.Equal(t, second.ID(), images[0].ID())
+	images, err = ir.GetImagesByLabel(ctx, "build-id", "123")
+	assert.NoError(t, err)
+	assert.NotNil(t, images)
+	assert.Empty(t, images)
+
+	_, err = ir.GetImagesByLabel(ctx, "", "1234")
+	assert.Error(t, err)
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
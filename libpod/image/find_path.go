@@ -0,0 +1,149 @@
+package image
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/containers/image/docker/reference"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// maxSymlinks is the number of symlinks followed when resolving a path inside
+// an image before giving up
+const maxSymlinks = 255
+
+// FindImagesContainingPath returns the IDs of the local images whose
+// filesystem contains the given absolute path.  If nameGlob is not empty, only
+// images with a name matching it, in full or familiar form (such as
+// "alpine:latest"), are searched.
+//
+// This is expensive: the top layer of every image searched is mounted in turn
+// so that the path can be looked up in its merged filesystem.  Images sharing
+// a top layer are only searched once.  Each layer is unmounted as soon as it
+// has been searched, and the search stops, with ctx's error, when ctx is done.
+func (ir *Runtime) FindImagesContainingPath(ctx context.Context, path, nameGlob string) ([]string, error) {
+	if !filepath.IsAbs(path) {
+		return nil, errors.Errorf("path %q must be absolute", path)
+	}
+	if nameGlob != "" {
+		if _, err := filepath.Match(nameGlob, ""); err != nil {
+			return nil, errors.Wrapf(err, "invalid name pattern %q", nameGlob)
+		}
+	}
+	images, err := ir.GetImages()
+	if err != nil {
+		return nil, err
+	}
+
+	var found []string
+	layerHasPath := make(map[string]bool)
+	for _, img := range images {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if nameGlob != "" && !nameMatches(img.Names(), nameGlob) {
+			continue
+		}
+		topLayer := img.TopLayer()
+		if topLayer == "" {
+			continue
+		}
+		hasPath, ok := layerHasPath[topLayer]
+		if !ok {
+			hasPath, err = ir.layerContainsPath(topLayer, path)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to search image %s", img.ID())
+			}
+			layerHasPath[topLayer] = hasPath
+		}
+		if hasPath {
+			found = append(found, img.ID())
+		}
+	}
+	return found, nil
+}
+
+// nameMatches returns true if any of the names matches the glob, in full or
+// familiar form
+func nameMatches(names []string, glob string) bool {
+	for _, name := range names {
+		if matched, _ := filepath.Match(glob, name); matched {
+			return true
+		}
+		named, err := reference.ParseNormalizedNamed(name)
+		if err != nil {
+			continue
+		}
+		if matched, _ := filepath.Match(glob, reference.FamiliarString(named)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// layerContainsPath mounts a layer, along with the layers below it, and
+// reports whether the path exists in it
+func (ir *Runtime) layerContainsPath(layerID, path string) (bool, error) {
+	mountPoint, err := ir.store.Mount(layerID, "")
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to mount layer %s", layerID)
+	}
+	defer func() {
+		if err := ir.store.Unmount(layerID); err != nil {
+			logrus.Errorf("unable to unmount layer %s: %v", layerID, err)
+		}
+	}()
+	return existsInRoot(mountPoint, path)
+}
+
+// existsInRoot reports whether path exists in the filesystem rooted at root.
+// Symlinks among the leading components of path are resolved inside root,
+// never on the host.  The final component is not followed, so a dangling
+// symlink exists.
+func existsInRoot(root, path string) (bool, error) {
+	current := "/"
+	remaining := strings.Split(path, "/")
+	followed := 0
+	for len(remaining) > 0 {
+		component := remaining[0]
+		remaining = remaining[1:]
+		switch component {
+		case "", ".":
+			continue
+		case "..":
+			current = filepath.Dir(current)
+			continue
+		}
+		next := filepath.Join(current, component)
+		info, err := os.Lstat(filepath.Join(root, next))
+		if err != nil {
+			// A leading component which is not a directory means
+			// the path cannot exist either
+			if pathErr, ok := err.(*os.PathError); os.IsNotExist(err) || (ok && pathErr.Err == syscall.ENOTDIR) {
+				return false, nil
+			}
+			return false, err
+		}
+		if info.Mode()&os.ModeSymlink != 0 && len(remaining) > 0 {
+			followed++
+			if followed > maxSymlinks {
+				return false, errors.Errorf("too many levels of symbolic links resolving %q", path)
+			}
+			target, err := os.Readlink(filepath.Join(root, next))
+			if err != nil {
+				return false, err
+			}
+			if filepath.IsAbs(target) {
+				current = "/"
+			}
+			remaining = append(strings.Split(target, "/"), remaining...)
+			continue
+		}
+		current = next
+	}
+	return true, nil
+}
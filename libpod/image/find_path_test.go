@@ -0,0 +1,52 @@
+package image
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExistsInRoot(t *testing.T) {
+	root, err := ioutil.TempDir("", "find-path-test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "usr", "bin"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "usr", "bin", "foo"), []byte("foo"), 0755))
+	// Absolute and relative symlinks must resolve inside the root
+	assert.NoError(t, os.Symlink("/usr/bin", filepath.Join(root, "bin")))
+	assert.NoError(t, os.Symlink("usr/bin", filepath.Join(root, "relbin")))
+	assert.NoError(t, os.Symlink("/", filepath.Join(root, "host")))
+	assert.NoError(t, os.Symlink("/does/not/exist", filepath.Join(root, "dangling")))
+
+	for path, exists := range map[string]bool{
+		"/usr/bin/foo":          true,
+		"/bin/foo":              true,
+		"/relbin/foo":           true,
+		"/usr/bin/../bin/foo":   true,
+		"/../../usr/bin/foo":    true,
+		"/dangling":             true,
+		"/usr/bin/bar":          false,
+		"/usr/bin/foo/bar":      false,
+		"/host" + root + "/usr": false,
+		"/dangling/foo":         false,
+	} {
+		found, err := existsInRoot(root, path)
+		assert.NoError(t, err, path)
+		assert.Equal(t, exists, found, path)
+	}
+
+	assert.NoError(t, os.Symlink("/loop", filepath.Join(root, "loop")))
+	_, err = existsInRoot(root, "/loop/foo")
+	assert.Error(t, err)
+}
+
+func TestNameMatches(t *testing.T) {
+	names := []string{"docker.io/library/alpine:latest"}
+	assert.True(t, nameMatches(names, "docker.io/library/alpine:*"))
+	assert.True(t, nameMatches(names, "alpine:*"))
+	assert.False(t, nameMatches(names, "fedora*"))
+}
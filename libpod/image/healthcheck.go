@@ -0,0 +1,35 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/containers/image/manifest"
+	"github.com/pkg/errors"
+)
+
+// Healthcheck returns the healthcheck recorded in the image's configuration by
+// a Dockerfile HEALTHCHECK instruction, or nil if the image has none or it was
+// disabled.  Only Docker-format images can record a healthcheck.
+func (i *Image) Healthcheck(ctx context.Context) (*manifest.Schema2HealthConfig, error) {
+	imgRef, err := i.toImageRef(ctx)
+	if err != nil {
+		return nil, err
+	}
+	configBlob, err := imgRef.ConfigBlob(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get configuration of image %s", i.ID())
+	}
+	var config manifest.Schema2Image
+	if err := json.Unmarshal(configBlob, &config); err != nil {
+		return nil, errors.Wrapf(err, "unable to decode configuration of image %s", i.ID())
+	}
+	if config.Config == nil || config.Config.Healthcheck == nil {
+		return nil, nil
+	}
+	healthcheck := config.Config.Healthcheck
+	if len(healthcheck.Test) == 0 || healthcheck.Test[0] == "NONE" {
+		return nil, nil
+	}
+	return healthcheck, nil
+}
@@ -0,0 +1,40 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/containers/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImage_Healthcheck(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:   workdir,
+		GraphRoot: workdir,
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	checked := createConfiguredImage(t, ir, "docker.io/library/checked:latest", `{"Healthcheck":{"Test":["CMD-SHELL","curl -f http://localhost/"],"Interval":30000000000,"Retries":3}}`)
+	healthcheck, err := checked.Healthcheck(ctx)
+	assert.NoError(t, err)
+	assert.NotNil(t, healthcheck)
+	assert.Equal(t, []string{"CMD-SHELL", "curl -f http://localhost/"}, healthcheck.Test)
+	assert.Equal(t, 3, healthcheck.Retries)
+
+	// Images without a healthcheck, or with it disabled, have none
+	for n, runConfig := range []string{`{}`, `{"Healthcheck":{"Test":["NONE"]}}`, `{"Healthcheck":{}}`} {
+		img := createConfiguredImage(t, ir, fmt.Sprintf("docker.io/library/unchecked:%d", n), runConfig)
+		healthcheck, err := img.Healthcheck(ctx)
+		assert.NoError(t, err)
+		assert.Nil(t, healthcheck, runConfig)
+	}
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	goruntime "runtime"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -29,6 +31,7 @@ import (
 	"github.com/projectatomic/libpod/pkg/registries"
 	"github.com/projectatomic/libpod/pkg/util"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
 )
 
 // imageConversions is used to cache image "cast" types
@@ -58,6 +61,9 @@ type Image struct {
 type Runtime struct {
 	store               storage.Store
 	SignaturePolicyPath string
+	// transferLimiter, if set, limits the rate of image transfers
+	transferLimiter *rate.Limiter
+	transferLock    sync.Mutex
 }
 
 // NewImageRuntimeFromStore creates an ImageRuntime based on a provided store
@@ -404,6 +410,34 @@ func (ir *Runtime) GetImages() ([]*Image, error) {
 	return newImages, nil
 }
 
+// GetImagesByLabel returns the local images whose label key is set to value.
+// As labels are not unique, any number of images may match; if none do, an
+// empty list is returned.
+func (ir *Runtime) GetImagesByLabel(ctx context.Context, key, value string) ([]*Image, error) {
+	if key == "" {
+		return nil, errors.Errorf("label key must not be empty")
+	}
+	images, err := ir.GetImages()
+	if err != nil {
+		return nil, err
+	}
+	matches := []*Image{}
+	return append(matches, FilterImages(images, []ResultFilter{LabelValueFilter(ctx, key, value)})...), nil
+}
+
+// ImagesExist reports, for each of the given names, whether it resolves to an
+// image in local storage.  Names are resolved the same way NewFromLocal resolves
+// them; names which cannot be resolved, including ambiguous ones, are reported
+// as absent.
+func (ir *Runtime) ImagesExist(names []string) map[string]bool {
+	exist := make(map[string]bool, len(names))
+	for _, name := range names {
+		_, err := ir.NewFromLocal(name)
+		exist[name] = err == nil
+	}
+	return exist
+}
+
 // getImageDigest creates an image object and uses the hex value of the digest as the image ID
 // for parsing the store reference
 func getImageDigest(ctx context.Context, src types.ImageReference, sc *types.SystemContext) (string, error) {
@@ -421,21 +455,34 @@ func getImageDigest(ctx context.Context, src types.ImageReference, sc *types.Sys
 
 // TagImage adds a tag to the given image
 func (i *Image) TagImage(tag string) error {
+	return i.TagImages([]string{tag})
+}
+
+// TagImages adds several tags to the given image at once, so that either all
+// or none of them are added
+func (i *Image) TagImages(tags []string) error {
 	i.reloadImage()
-	decomposedTag, err := decompose(tag)
-	if err != nil {
-		return err
-	}
-	// If the input does not have a tag, we need to add one (latest)
-	if !decomposedTag.isTagged {
-		tag = fmt.Sprintf("%s:%s", tag, decomposedTag.tag)
+	names := i.Names()
+	added := false
+	for _, tag := range tags {
+		decomposedTag, err := decompose(tag)
+		if err != nil {
+			return err
+		}
+		// If the input does not have a tag, we need to add one (latest)
+		if !decomposedTag.isTagged {
+			tag = fmt.Sprintf("%s:%s", tag, decomposedTag.tag)
+		}
+		if util.StringInSlice(tag, names) {
+			continue
+		}
+		names = append(names, tag)
+		added = true
 	}
-	tags := i.Names()
-	if util.StringInSlice(tag, tags) {
+	if !added {
 		return nil
 	}
-	tags = append(tags, tag)
-	if err := i.imageruntime.store.SetNames(i.ID(), tags); err != nil {
+	if err := i.imageruntime.store.SetNames(i.ID(), names); err != nil {
 		return err
 	}
 	i.reloadImage()
@@ -462,6 +509,47 @@ func (i *Image) UntagImage(tag string) error {
 	return nil
 }
 
+// ReplaceImage moves all the tags of the image named oldName to the local
+// image newImageID, and returns the tags moved.  The tags are moved in a
+// single update of the image store, so that every tag always names one of the
+// two images.  If removeOld is set, the old image is then removed; if that
+// fails, the tags stay moved and the error is returned along with them.
+func (ir *Runtime) ReplaceImage(oldName, newImageID string, removeOld bool) ([]string, error) {
+	oldImage, err := ir.NewFromLocal(oldName)
+	if err != nil {
+		return nil, err
+	}
+	newImage, err := ir.NewFromLocal(newImageID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to find replacement image %s", newImageID)
+	}
+	if newImage.ID() == oldImage.ID() {
+		return nil, errors.Errorf("image %s cannot replace itself", oldImage.ID())
+	}
+
+	tags := oldImage.Names()
+	if len(tags) > 0 {
+		names := newImage.Names()
+		for _, tag := range tags {
+			if !util.StringInSlice(tag, names) {
+				names = append(names, tag)
+			}
+		}
+		// Setting the names on the new image removes them from the old
+		// one in the same update
+		if err := ir.store.SetNames(newImage.ID(), names); err != nil {
+			return nil, errors.Wrapf(err, "unable to move tags of image %s to image %s", oldImage.ID(), newImage.ID())
+		}
+	}
+
+	if removeOld {
+		if err := oldImage.Remove(false); err != nil {
+			return tags, errors.Wrapf(err, "moved tags to image %s but unable to remove image %s", newImage.ID(), oldImage.ID())
+		}
+	}
+	return tags, nil
+}
+
 // PushImage pushes the given image to a location described by the given path
 func (i *Image) PushImage(ctx context.Context, destination, manifestMIMEType, authFile, signaturePolicyPath string, writer io.Writer, forceCompress bool, signingOptions SigningOptions, dockerRegistryOptions *DockerRegistryOptions, forceSecure bool, additionalDockerArchiveTags []reference.NamedTagged) error {
 	if destination == "" {
@@ -513,13 +601,52 @@ func (i *Image) PushImage(ctx context.Context, destination, manifestMIMEType, au
 		}
 	}
 	// Copy the image to the remote destination
-	err = cp.Image(ctx, policyContext, dest, src, copyOptions)
+	err = cp.Image(ctx, policyContext, dest, i.imageruntime.limitTransferRate(src), copyOptions)
 	if err != nil {
 		return errors.Wrapf(err, "Error copying image to the remote destination")
 	}
 	return nil
 }
 
+// ConvertFormat writes a copy of the image to local storage as newName, with its
+// manifest and configuration rewritten in the given format, either "oci" or
+// "docker".  The layers of the original image are reused rather than copied.
+func (i *Image) ConvertFormat(ctx context.Context, newName, format string, writer io.Writer) (*Image, error) {
+	var manifestType string
+	switch format {
+	case "oci":
+		manifestType = ociv1.MediaTypeImageManifest
+	case "docker":
+		manifestType = manifest.DockerV2Schema2MediaType
+	default:
+		return nil, errors.Errorf("unknown image format %q, must be oci or docker", format)
+	}
+
+	src, err := is.Transport.ParseStoreReference(i.imageruntime.store, i.ID())
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting source imageReference for %q", i.InputName)
+	}
+	dest, err := is.Transport.ParseStoreReference(i.imageruntime.store, newName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting image reference for %q", newName)
+	}
+
+	sc := GetSystemContext(i.imageruntime.SignaturePolicyPath, "", false)
+	policyContext, err := getPolicyContext(sc)
+	if err != nil {
+		return nil, err
+	}
+	defer policyContext.Destroy()
+
+	// Signatures cover the original manifest and cannot survive the rewrite
+	signingOptions := SigningOptions{RemoveSignatures: true}
+	copyOptions := getCopyOptions(writer, i.imageruntime.SignaturePolicyPath, nil, nil, signingOptions, "", manifestType, false, nil)
+	if err = cp.Image(ctx, policyContext, dest, src, copyOptions); err != nil {
+		return nil, errors.Wrapf(err, "error converting image %s to %s format", i.ID(), format)
+	}
+	return i.imageruntime.NewFromLocal(newName)
+}
+
 // MatchesID returns a bool based on if the input id
 // matches the image's id
 func (i *Image) MatchesID(id string) bool {
@@ -665,6 +792,16 @@ func (i *Image) ociv1Image(ctx context.Context) (*ociv1.Image, error) {
 	return imgRef.OCIConfig(ctx)
 }
 
+// Config returns the runtime configuration recorded in the image, such as its
+// entrypoint, command, and environment
+func (i *Image) Config(ctx context.Context) (*ociv1.ImageConfig, error) {
+	ociv1Img, err := i.ociv1Image(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &ociv1Img.Config, nil
+}
+
 func (i *Image) imageInspectInfo(ctx context.Context) (*types.ImageInspectInfo, error) {
 	if i.inspectInfo == nil {
 		sr, err := i.toStorageReference()
@@ -867,6 +1004,45 @@ func (i *Image) Containers() ([]string, error) {
 	return imageContainers, err
 }
 
+// ImageContainer identifies a container created from an image
+type ImageContainer struct {
+	ID    string
+	Names []string
+}
+
+// ContainersByImage returns the containers in storage grouped by the ID of the
+// image they were created from.  It makes a single pass over the containers,
+// rather than one per image as calling Containers on each image would.  If
+// includeUnused is true, images without containers are included with an empty
+// list.  Like Containers, this includes containers created by other tools
+// sharing the storage, such as buildah.
+func (ir *Runtime) ContainersByImage(includeUnused bool) (map[string][]ImageContainer, error) {
+	containers, err := ir.store.Containers()
+	if err != nil {
+		return nil, err
+	}
+	byImage := make(map[string][]ImageContainer)
+	if includeUnused {
+		images, err := ir.store.Images()
+		if err != nil {
+			return nil, err
+		}
+		for _, img := range images {
+			byImage[img.ID] = []ImageContainer{}
+		}
+	}
+	for _, c := range containers {
+		if c.ImageID == "" {
+			continue
+		}
+		byImage[c.ImageID] = append(byImage[c.ImageID], ImageContainer{
+			ID:    c.ID,
+			Names: c.Names,
+		})
+	}
+	return byImage, nil
+}
+
 // Comment returns the Comment for an image depending on its ManifestType
 func (i *Image) Comment(ctx context.Context, manifestType string) (string, error) {
 	if manifestType == buildah.Dockerv2ImageManifest {
@@ -890,3 +1066,81 @@ func (i *Image) Comment(ctx context.Context, manifestType string) (string, error
 	}
 	return ociv1Img.History[0].Comment, nil
 }
+
+// platformList is the part of a manifest list or image index describing the
+// images it lists and their platforms
+type platformList struct {
+	Manifests []struct {
+		MediaType string        `json:"mediaType"`
+		Digest    digest.Digest `json:"digest"`
+		Platform  struct {
+			Architecture string `json:"architecture"`
+			OS           string `json:"os"`
+			Variant      string `json:"variant,omitempty"`
+		} `json:"platform"`
+	} `json:"manifests"`
+}
+
+// ForeignArch returns true if the image is for an architecture other than the
+// host's.  An image whose manifest is a manifest list or image index is only
+// foreign if none of the images it lists are for the host's architecture.  An
+// image which does not record its architecture is not considered foreign.
+func (i *Image) ForeignArch(ctx context.Context) (bool, error) {
+	storeRef, err := is.Transport.ParseStoreReference(i.imageruntime.store, i.ID())
+	if err != nil {
+		return false, err
+	}
+	src, err := storeRef.NewImageSource(ctx, &types.SystemContext{})
+	if err != nil {
+		return false, err
+	}
+	defer src.Close()
+	manifestBlob, manifestType, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to get manifest of image %s", i.ID())
+	}
+	if manifestType == "" {
+		manifestType = manifest.GuessMIMEType(manifestBlob)
+	}
+
+	if manifestType == manifest.DockerV2ListMediaType || manifestType == ociv1.MediaTypeImageIndex {
+		var list platformList
+		if err := json.Unmarshal(manifestBlob, &list); err != nil {
+			return false, errors.Wrapf(err, "unable to parse manifest list of image %s", i.ID())
+		}
+		for _, m := range list.Manifests {
+			if m.Platform.Architecture == goruntime.GOARCH {
+				return false, nil
+			}
+		}
+		return len(list.Manifests) > 0, nil
+	}
+
+	ociv1Img, err := i.ociv1Image(ctx)
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to get configuration of image %s", i.ID())
+	}
+	return ociv1Img.Architecture != "" && ociv1Img.Architecture != goruntime.GOARCH, nil
+}
+
+// ForeignArchImages returns the images in local storage which are for an
+// architecture other than the host's, as determined by ForeignArch.  Images
+// whose architecture cannot be determined are skipped.
+func (ir *Runtime) ForeignArchImages(ctx context.Context) ([]*Image, error) {
+	images, err := ir.GetImages()
+	if err != nil {
+		return nil, err
+	}
+	var foreign []*Image
+	for _, img := range images {
+		isForeign, err := img.ForeignArch(ctx)
+		if err != nil {
+			logrus.Debugf("unable to determine the architecture of image %s: %v", img.ID(), err)
+			continue
+		}
+		if isForeign {
+			foreign = append(foreign, img)
+		}
+	}
+	return foreign, nil
+}
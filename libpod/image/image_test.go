@@ -6,9 +6,13 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	goruntime "runtime"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/containers/storage"
+	"github.com/opencontainers/go-digest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -138,6 +142,173 @@ func TestImage_New(t *testing.T) {
 	cleanup(workdir, ir)
 }
 
+// TestImage_ImagesExist tests checking a mix of present and absent images at once
+func TestImage_ImagesExist(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:   workdir,
+		GraphRoot: workdir,
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+
+	// An image without layers is enough to be found locally
+	_, err = ir.store.CreateImage("", []string{"docker.io/library/present:latest"}, "", "", &storage.ImageOptions{})
+	assert.NoError(t, err)
+
+	exist := ir.ImagesExist([]string{"present", "docker.io/library/present:latest", "absent", "docker.io/library/absent:latest"})
+	assert.Equal(t, map[string]bool{
+		"present":                          true,
+		"docker.io/library/present:latest": true,
+		"absent":                           false,
+		"docker.io/library/absent:latest":  false,
+	}, exist)
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
+
+func TestImage_TagImages(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:   workdir,
+		GraphRoot: workdir,
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+
+	_, err = ir.store.CreateImage("", []string{"docker.io/library/committed:latest"}, "", "", &storage.ImageOptions{})
+	assert.NoError(t, err)
+	committed, err := ir.NewFromLocal("committed")
+	assert.NoError(t, err)
+
+	names := []string{"docker.io/library/committed:1.0", "docker.io/library/committed:1", "registry.example.com/committed"}
+	assert.NoError(t, committed.TagImages(names))
+	assert.Equal(t, []string{"docker.io/library/committed:latest", "docker.io/library/committed:1.0", "docker.io/library/committed:1", "registry.example.com/committed:latest"}, committed.Names())
+	for _, name := range append(names, "committed") {
+		img, err := ir.NewFromLocal(name)
+		assert.NoError(t, err, name)
+		assert.Equal(t, committed.ID(), img.ID(), name)
+	}
+
+	// Invalid names are rejected without adding any of the names
+	assert.Error(t, committed.TagImages([]string{"docker.io/library/committed:2", "Invalid Name"}))
+	assert.Len(t, committed.Names(), 4)
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
+
+func TestImage_ContainersByImage(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:         workdir,
+		GraphRoot:       workdir,
+		GraphDriverName: "vfs",
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+
+	// Containers need a layer to be created on
+	layer, err := ir.store.CreateLayer("", "", nil, "", false, nil)
+	assert.NoError(t, err)
+	used, err := ir.store.CreateImage("", []string{"docker.io/library/used:latest"}, layer.ID, "", &storage.ImageOptions{})
+	assert.NoError(t, err)
+	unused, err := ir.store.CreateImage("", []string{"docker.io/library/unused:latest"}, "", "", &storage.ImageOptions{})
+	assert.NoError(t, err)
+	ctr1, err := ir.store.CreateContainer("", []string{"ctr1"}, used.ID, "", "", nil)
+	assert.NoError(t, err)
+	ctr2, err := ir.store.CreateContainer("", []string{"ctr2"}, used.ID, "", "", nil)
+	assert.NoError(t, err)
+
+	byImage, err := ir.ContainersByImage(false)
+	assert.NoError(t, err)
+	assert.Len(t, byImage, 1)
+	assert.Len(t, byImage[used.ID], 2)
+	for _, c := range byImage[used.ID] {
+		switch c.ID {
+		case ctr1.ID:
+			assert.Equal(t, []string{"ctr1"}, c.Names)
+		case ctr2.ID:
+			assert.Equal(t, []string{"ctr2"}, c.Names)
+		default:
+			t.Errorf("unexpected container %s", c.ID)
+		}
+	}
+
+	byImage, err = ir.ContainersByImage(true)
+	assert.NoError(t, err)
+	assert.Len(t, byImage, 2)
+	assert.Len(t, byImage[used.ID], 2)
+	assert.Empty(t, byImage[unused.ID])
+	_, ok := byImage[unused.ID]
+	assert.True(t, ok)
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
+
+// createArchImage creates an image without layers in the runtime's storage
+// whose configuration records the given architecture
+func createArchImage(t *testing.T, ir *Runtime, name, arch string) {
+	config := []byte(fmt.Sprintf(`{"architecture":%q,"os":"linux","config":{},"rootfs":{"type":"layers","diff_ids":[]}}`, arch))
+	configDigest := digest.FromBytes(config)
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{"mediaType":"application/vnd.docker.container.image.v1+json","size":%d,"digest":%q},"layers":[]}`, len(config), configDigest))
+	img, err := ir.store.CreateImage("", []string{name}, "", "", &storage.ImageOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, ir.store.SetImageBigData(img.ID, "manifest", manifest))
+	assert.NoError(t, ir.store.SetImageBigData(img.ID, configDigest.String(), config))
+}
+
+// createListImage creates an image in the runtime's storage whose manifest is
+// a manifest list of images for the given architectures
+func createListImage(t *testing.T, ir *Runtime, name string, archs ...string) {
+	var entries []string
+	for _, arch := range archs {
+		entries = append(entries, fmt.Sprintf(`{"mediaType":"application/vnd.docker.distribution.manifest.v2+json","size":1,"digest":%q,"platform":{"architecture":%q,"os":"linux"}}`, digest.FromString(arch), arch))
+	}
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.list.v2+json","manifests":[%s]}`, strings.Join(entries, ",")))
+	img, err := ir.store.CreateImage("", []string{name}, "", "", &storage.ImageOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, ir.store.SetImageBigData(img.ID, "manifest", manifest))
+}
+
+// TestImage_ForeignArchImages tests finding the images for other architectures
+func TestImage_ForeignArchImages(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:   workdir,
+		GraphRoot: workdir,
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+
+	foreignArch := "s390x"
+	if goruntime.GOARCH == foreignArch {
+		foreignArch = "ppc64le"
+	}
+	createArchImage(t, ir, "docker.io/library/native:latest", goruntime.GOARCH)
+	createArchImage(t, ir, "docker.io/library/foreign:latest", foreignArch)
+	createListImage(t, ir, "docker.io/library/nativelist:latest", foreignArch, goruntime.GOARCH)
+	createListImage(t, ir, "docker.io/library/foreignlist:latest", foreignArch)
+
+	images, err := ir.ForeignArchImages(context.Background())
+	assert.NoError(t, err)
+	var names []string
+	for _, img := range images {
+		names = append(names, img.Names()...)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"docker.io/library/foreign:latest", "docker.io/library/foreignlist:latest"}, names)
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
+
 // TestImage_MatchRepoTag tests the various inputs we need to match
 // against an image's reponames
 func TestImage_MatchRepoTag(t *testing.T) {
@@ -198,3 +369,54 @@ func Test_stripSha256(t *testing.T) {
 	assert.Equal(t, stripSha256("sha256:"), "sha256:")
 	assert.Equal(t, stripSha256("sha256:a"), "a")
 }
+
+func TestRuntime_ReplaceImage(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:   workdir,
+		GraphRoot: workdir,
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+
+	oldImage, err := ir.store.CreateImage("", []string{"docker.io/library/base:latest", "docker.io/library/base:1"}, "", "", &storage.ImageOptions{})
+	assert.NoError(t, err)
+	newImage, err := ir.store.CreateImage("", []string{"docker.io/library/base:build"}, "", "", &storage.ImageOptions{})
+	assert.NoError(t, err)
+	untagged, err := ir.store.CreateImage("", nil, "", "", &storage.ImageOptions{})
+	assert.NoError(t, err)
+
+	// The replacement must exist, and cannot be the old image
+	_, err = ir.ReplaceImage("base:latest", "0123456789abcdef", false)
+	assert.Error(t, err)
+	_, err = ir.ReplaceImage("base:latest", oldImage.ID, false)
+	assert.Error(t, err)
+	_, err = ir.ReplaceImage("missing", newImage.ID, false)
+	assert.Error(t, err)
+
+	tags, err := ir.ReplaceImage("base:latest", newImage.ID, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"docker.io/library/base:latest", "docker.io/library/base:1"}, tags)
+	for _, name := range []string{"base:latest", "base:1", "base:build"} {
+		img, err := ir.NewFromLocal(name)
+		assert.NoError(t, err, name)
+		assert.Equal(t, newImage.ID, img.ID(), name)
+	}
+	// The old image is kept, without tags
+	old, err := ir.NewFromLocal(oldImage.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, old.Names())
+
+	// Tags move on, and the old image can be removed
+	tags, err = ir.ReplaceImage("base:1", untagged.ID, true)
+	assert.NoError(t, err)
+	assert.Len(t, tags, 3)
+	replaced, err := ir.NewFromLocal(untagged.ID)
+	assert.NoError(t, err)
+	assert.Len(t, replaced.Names(), 3)
+	assert.Equal(t, map[string]bool{newImage.ID: false, oldImage.ID: true}, ir.ImagesExist([]string{newImage.ID, oldImage.ID}))
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
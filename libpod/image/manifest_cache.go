@@ -0,0 +1,160 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/containers/image/manifest"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+// ImageIndex describes a manifest list or image index cached by
+// PullImageIndex, or a single manifest if the image had no list
+type ImageIndex struct {
+	// Digest is the digest of the manifest list, or of the manifest
+	Digest digest.Digest
+	// MediaType is the media type of the manifest list, or of the manifest
+	MediaType string
+	// Platforms are the per-platform manifests which were cached
+	Platforms []IndexPlatform
+}
+
+// IndexPlatform describes a per-platform manifest cached by PullImageIndex.
+// The platform is only known for manifests listed in a manifest list.
+type IndexPlatform struct {
+	Digest       digest.Digest
+	MediaType    string
+	OS           string
+	Architecture string
+	Variant      string
+}
+
+// manifestCacheDir returns the directory manifests are cached in
+func (ir *Runtime) manifestCacheDir() string {
+	return filepath.Join(ir.store.GraphRoot(), "libpod", "manifests")
+}
+
+// PullImageIndex fetches the manifest list or image index of the named image
+// from its registry, without selecting a platform, and caches it along with
+// the manifests of all the platforms it lists.  No layers are pulled.  If the
+// image has a single manifest rather than a list, just that manifest is
+// cached.  Cached manifests are stored by digest and can be read back with
+// CachedManifest without contacting the registry.
+func (ir *Runtime) PullImageIndex(ctx context.Context, name, authfile string, dockerOptions *DockerRegistryOptions) (*ImageIndex, error) {
+	src, err := openRegistrySource(ctx, name, authfile, dockerOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	listBlob, listType, err := getRemoteManifest(ctx, src, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	if listType == "" {
+		listType = manifest.GuessMIMEType(listBlob)
+	}
+	listDigest, err := ir.cacheManifest(listBlob)
+	if err != nil {
+		return nil, err
+	}
+	index := &ImageIndex{
+		Digest:    listDigest,
+		MediaType: listType,
+	}
+
+	if listType != manifest.DockerV2ListMediaType && listType != ociv1.MediaTypeImageIndex {
+		index.Platforms = append(index.Platforms, IndexPlatform{
+			Digest:    listDigest,
+			MediaType: listType,
+		})
+		return index, nil
+	}
+
+	var list platformList
+	if err := json.Unmarshal(listBlob, &list); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse manifest list of %q", name)
+	}
+	for _, m := range list.Manifests {
+		instanceDigest := m.Digest
+		manifestBlob, _, err := getRemoteManifest(ctx, src, name, &instanceDigest)
+		if err != nil {
+			return nil, err
+		}
+		if !digestMatches(manifestBlob, instanceDigest) {
+			return nil, errors.Errorf("manifest %s of %q does not match its digest", instanceDigest, name)
+		}
+		if _, err := ir.cacheManifest(manifestBlob); err != nil {
+			return nil, err
+		}
+		index.Platforms = append(index.Platforms, IndexPlatform{
+			Digest:       instanceDigest,
+			MediaType:    m.MediaType,
+			OS:           m.Platform.OS,
+			Architecture: m.Platform.Architecture,
+			Variant:      m.Platform.Variant,
+		})
+	}
+	return index, nil
+}
+
+// CachedManifest returns a manifest cached by PullImageIndex
+func (ir *Runtime) CachedManifest(manifestDigest digest.Digest) ([]byte, error) {
+	if err := manifestDigest.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid manifest digest %q", manifestDigest)
+	}
+	manifestBlob, err := ioutil.ReadFile(filepath.Join(ir.manifestCacheDir(), manifestDigest.Algorithm().String(), manifestDigest.Hex()))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Errorf("manifest %s is not cached", manifestDigest)
+		}
+		return nil, err
+	}
+	return manifestBlob, nil
+}
+
+// cacheManifest stores a manifest in the cache under its digest
+func (ir *Runtime) cacheManifest(manifestBlob []byte) (digest.Digest, error) {
+	manifestDigest, err := manifest.Digest(manifestBlob)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(ir.manifestCacheDir(), manifestDigest.Algorithm().String())
+	if err := writeFileAtomic(dir, manifestDigest.Hex(), manifestBlob); err != nil {
+		return "", errors.Wrapf(err, "unable to cache manifest %s", manifestDigest)
+	}
+	return manifestDigest, nil
+}
+
+// writeFileAtomic writes data to the named file in dir, creating dir if
+// needed.  The data is written to a temporary file first, so that the file is
+// never seen partially written.
+func writeFileAtomic(dir, name string, data []byte) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
+
+// digestMatches returns true if the manifest has the given digest
+func digestMatches(manifestBlob []byte, manifestDigest digest.Digest) bool {
+	matches, err := manifest.MatchesDigest(manifestBlob, manifestDigest)
+	return err == nil && matches
+}
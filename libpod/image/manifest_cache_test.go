@@ -0,0 +1,36 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/containers/storage"
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifestCache(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:   workdir,
+		GraphRoot: workdir,
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+
+	manifestDigest, err := ir.cacheManifest([]byte(testManifest))
+	assert.NoError(t, err)
+	assert.Equal(t, digest.FromString(testManifest), manifestDigest)
+
+	cached, err := ir.CachedManifest(manifestDigest)
+	assert.NoError(t, err)
+	assert.Equal(t, testManifest, string(cached))
+
+	_, err = ir.CachedManifest(digest.FromString("not cached"))
+	assert.Error(t, err)
+	_, err = ir.CachedManifest("sha256:../../etc/passwd")
+	assert.Error(t, err)
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
@@ -0,0 +1,422 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containers/image/docker/reference"
+	"github.com/containers/image/manifest"
+	"github.com/containers/image/transports/alltransports"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/projectatomic/libpod/pkg/registries"
+	"github.com/projectatomic/libpod/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrNoSuchManifestList indicates that no manifest list with the requested
+// name was created
+var ErrNoSuchManifestList = errors.New("no such manifest list")
+
+// ManifestList is a manifest list, or image index, created from local images
+// by CreateManifestList
+type ManifestList struct {
+	// Name is the name of the list, in normalized form
+	Name string
+	// MediaType is the media type of the list: a Docker manifest list if
+	// the images have Docker manifests, or an OCI image index if they have
+	// OCI manifests
+	MediaType string
+	// Digest is the digest of the list
+	Digest digest.Digest
+	// Members are the images in the list, one per platform
+	Members []ManifestListMember
+}
+
+// ManifestListMember describes an image in a manifest list
+type ManifestListMember struct {
+	// ImageID is the ID of the local image
+	ImageID      string
+	Digest       digest.Digest
+	MediaType    string
+	Size         int64
+	OS           string
+	Architecture string
+	Variant      string
+}
+
+// manifestListEntry is an entry of a manifest list or image index, which
+// share a layout
+type manifestListEntry struct {
+	MediaType string        `json:"mediaType"`
+	Size      int64         `json:"size"`
+	Digest    digest.Digest `json:"digest"`
+	Platform  struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+		Variant      string `json:"variant,omitempty"`
+	} `json:"platform"`
+}
+
+// manifestListDir returns the directory manifest lists are stored in
+func (ir *Runtime) manifestListDir() string {
+	return filepath.Join(ir.store.GraphRoot(), "libpod", "manifest-lists")
+}
+
+// manifestListFile returns the name of the file a manifest list is stored in.
+// List names contain characters which cannot be used in file names, so the
+// file is named after the digest of the list's name instead.
+func manifestListFile(name string) string {
+	return digest.FromString(name).Hex() + ".json"
+}
+
+// normalizeManifestListName returns the normalized form of a list name, with
+// the default tag added if it has none
+func normalizeManifestListName(listName string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(listName)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid manifest list name %q", listName)
+	}
+	return reference.TagNameOnly(named).String(), nil
+}
+
+// CreateManifestList creates a manifest list named listName referencing the
+// given local images, replacing any list of the same name.  The platform of
+// each image is read from its configuration; every image must record its
+// operating system and architecture, and no two images may be for the same
+// platform.  The list is kept alongside local storage rather than in it, and
+// can be published with PushManifestList.
+func (ir *Runtime) CreateManifestList(ctx context.Context, listName string, imageNames []string) (*ManifestList, error) {
+	name, err := normalizeManifestListName(listName)
+	if err != nil {
+		return nil, err
+	}
+	if len(imageNames) == 0 {
+		return nil, errors.Errorf("manifest list %s must include at least one image", name)
+	}
+
+	list := &ManifestList{Name: name}
+	for _, imageName := range imageNames {
+		if err := list.addImage(ctx, ir, imageName); err != nil {
+			return nil, err
+		}
+	}
+	if err := ir.saveManifestList(list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// AddToManifestList adds a local image to the named manifest list.  The image
+// must record its operating system and architecture, and the list must not
+// already include an image for its platform.  The list is only changed
+// locally; it must be pushed again for a registry to see the change.
+func (ir *Runtime) AddToManifestList(ctx context.Context, listName, imageName string) (*ManifestList, error) {
+	list, err := ir.LookupManifestList(listName)
+	if err != nil {
+		return nil, err
+	}
+	if err := list.addImage(ctx, ir, imageName); err != nil {
+		return nil, err
+	}
+	if err := ir.saveManifestList(list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// RemoveFromManifestList removes the image whose manifest has the given
+// digest from the named manifest list.  The last image of a list cannot be
+// removed.  As with AddToManifestList, the list is only changed locally.
+func (ir *Runtime) RemoveFromManifestList(listName, manifestDigest string) (*ManifestList, error) {
+	list, err := ir.LookupManifestList(listName)
+	if err != nil {
+		return nil, err
+	}
+	removeDigest, err := digest.Parse(manifestDigest)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid manifest digest %q", manifestDigest)
+	}
+	for n, member := range list.Members {
+		if member.Digest != removeDigest {
+			continue
+		}
+		if len(list.Members) == 1 {
+			return nil, errors.Errorf("%s is the only manifest in manifest list %s and cannot be removed", removeDigest, list.Name)
+		}
+		list.Members = append(list.Members[:n], list.Members[n+1:]...)
+		if err := ir.saveManifestList(list); err != nil {
+			return nil, err
+		}
+		return list, nil
+	}
+	return nil, errors.Errorf("manifest list %s does not include manifest %s", list.Name, removeDigest)
+}
+
+// addImage adds a local image to the list, checking that its platform is
+// known and not already in the list
+func (l *ManifestList) addImage(ctx context.Context, ir *Runtime, imageName string) error {
+	img, err := ir.NewFromLocal(imageName)
+	if err != nil {
+		return err
+	}
+	member, err := img.manifestListMember(ctx)
+	if err != nil {
+		return err
+	}
+	if member.OS == "" || member.Architecture == "" {
+		return errors.Errorf("image %s does not record its operating system and architecture", imageName)
+	}
+	for _, other := range l.Members {
+		if other.platform() == member.platform() {
+			return errors.Errorf("manifest list %s already includes image %s for platform %s", l.Name, other.ImageID, member.platform())
+		}
+	}
+	l.Members = append(l.Members, *member)
+	// Check that the image can be included before accepting it
+	if _, err := l.manifestBlob(); err != nil {
+		l.Members = l.Members[:len(l.Members)-1]
+		return err
+	}
+	return nil
+}
+
+// saveManifestList stores the list, replacing any list of the same name
+func (ir *Runtime) saveManifestList(list *ManifestList) error {
+	if _, err := list.manifestBlob(); err != nil {
+		return err
+	}
+	listJSON, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(ir.manifestListDir(), manifestListFile(list.Name), listJSON); err != nil {
+		return errors.Wrapf(err, "unable to store manifest list %s", list.Name)
+	}
+	return nil
+}
+
+// LookupManifestList returns the manifest list created by CreateManifestList
+// with the given name
+func (ir *Runtime) LookupManifestList(listName string) (*ManifestList, error) {
+	name, err := normalizeManifestListName(listName)
+	if err != nil {
+		return nil, err
+	}
+	listJSON, err := ioutil.ReadFile(filepath.Join(ir.manifestListDir(), manifestListFile(name)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Wrapf(ErrNoSuchManifestList, "%s", name)
+		}
+		return nil, err
+	}
+	var list ManifestList
+	if err := json.Unmarshal(listJSON, &list); err != nil {
+		return nil, errors.Wrapf(err, "unable to decode manifest list %s", name)
+	}
+	return &list, nil
+}
+
+// PushManifestList publishes the named manifest list to destination, a
+// reference to a registry.  Each image in the list is pushed to destination
+// first, in the format of its local manifest, and the list is then rewritten
+// to refer to the manifests as the registry received them, since pushing may
+// compress layers and so change manifest digests.  As the images are pushed
+// to destination in turn, its tag refers to each image briefly before it
+// finally refers to the list.  The digest of the published list is returned.
+func (ir *Runtime) PushManifestList(ctx context.Context, listName, destination, authfile string, writer io.Writer, dockerOptions *DockerRegistryOptions, forceSecure bool) (digest.Digest, error) {
+	list, err := ir.LookupManifestList(listName)
+	if err != nil {
+		return "", err
+	}
+
+	dest, err := alltransports.ParseImageName(destination)
+	if err != nil {
+		dest, err = alltransports.ParseImageName(DefaultTransport + destination)
+		if err != nil {
+			return "", errors.Wrapf(err, "error parsing destination %q", destination)
+		}
+	}
+	if !strings.HasPrefix(DockerTransport, dest.Transport().Name()) || dest.DockerReference() == nil {
+		return "", errors.Errorf("%q does not refer to an image in a registry", destination)
+	}
+	destName := dest.DockerReference().String()
+
+	var registryOptions DockerRegistryOptions
+	if dockerOptions != nil {
+		registryOptions = *dockerOptions
+	}
+	insecureRegistries, err := registries.GetInsecureRegistries()
+	if err != nil {
+		return "", err
+	}
+	registry := reference.Domain(dest.DockerReference())
+	if util.StringInSlice(registry, insecureRegistries) && !forceSecure {
+		registryOptions.DockerInsecureSkipTLSVerify = true
+		logrus.Info(fmt.Sprintf("%s is an insecure registry; pushing with tls-verify=false", registry))
+	}
+
+	pushed := &ManifestList{Name: destName}
+	for _, member := range list.Members {
+		img, err := ir.NewFromLocal(member.ImageID)
+		if err != nil {
+			return "", errors.Wrapf(err, "unable to find image %s of manifest list %s", member.ImageID, list.Name)
+		}
+		if err := img.PushImage(ctx, destName, member.MediaType, authfile, "", writer, false, SigningOptions{}, &registryOptions, forceSecure, nil); err != nil {
+			return "", err
+		}
+		remote, err := pushedManifest(ctx, destName, authfile, &registryOptions)
+		if err != nil {
+			return "", err
+		}
+		remote.ImageID = member.ImageID
+		remote.OS = member.OS
+		remote.Architecture = member.Architecture
+		remote.Variant = member.Variant
+		pushed.Members = append(pushed.Members, *remote)
+	}
+
+	listBlob, err := pushed.manifestBlob()
+	if err != nil {
+		return "", err
+	}
+	sc := registryOptions.GetSystemContext("", authfile, false, nil)
+	imgDest, err := dest.NewImageDestination(ctx, sc)
+	if err != nil {
+		return "", errors.Wrapf(err, "error connecting to registry for %q", destName)
+	}
+	defer imgDest.Close()
+	if err := imgDest.PutManifest(ctx, listBlob); err != nil {
+		return "", errors.Wrapf(err, "unable to push manifest list %s to %q", list.Name, destName)
+	}
+	if err := imgDest.Commit(ctx); err != nil {
+		return "", errors.Wrapf(err, "unable to push manifest list %s to %q", list.Name, destName)
+	}
+	return pushed.Digest, nil
+}
+
+// pushedManifest reads back the manifest the registry holds for name
+func pushedManifest(ctx context.Context, name, authfile string, dockerOptions *DockerRegistryOptions) (*ManifestListMember, error) {
+	src, err := openRegistrySource(ctx, name, authfile, dockerOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	manifestBlob, manifestType, err := getRemoteManifest(ctx, src, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	manifestDigest, err := manifest.Digest(manifestBlob)
+	if err != nil {
+		return nil, err
+	}
+	if manifestType == "" {
+		manifestType = manifest.GuessMIMEType(manifestBlob)
+	}
+	return &ManifestListMember{
+		Digest:    manifestDigest,
+		MediaType: manifestType,
+		Size:      int64(len(manifestBlob)),
+	}, nil
+}
+
+// manifestListMember describes the image as a member of a manifest list
+func (i *Image) manifestListMember(ctx context.Context) (*ManifestListMember, error) {
+	imgRef, err := i.toImageRef(ctx)
+	if err != nil {
+		return nil, err
+	}
+	manifestBlob, manifestType, err := imgRef.Manifest(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get manifest of image %s", i.ID())
+	}
+	manifestDigest, err := manifest.Digest(manifestBlob)
+	if err != nil {
+		return nil, err
+	}
+	configBlob, err := imgRef.ConfigBlob(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get configuration of image %s", i.ID())
+	}
+	var config struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+		Variant      string `json:"variant"`
+	}
+	if err := json.Unmarshal(configBlob, &config); err != nil {
+		return nil, errors.Wrapf(err, "unable to decode configuration of image %s", i.ID())
+	}
+	return &ManifestListMember{
+		ImageID:      i.ID(),
+		Digest:       manifestDigest,
+		MediaType:    manifestType,
+		Size:         int64(len(manifestBlob)),
+		OS:           config.OS,
+		Architecture: config.Architecture,
+		Variant:      config.Variant,
+	}, nil
+}
+
+// platform returns the platform of the member in os/architecture[/variant]
+// form
+func (m *ManifestListMember) platform() string {
+	platform := m.OS + "/" + m.Architecture
+	if m.Variant != "" {
+		platform += "/" + m.Variant
+	}
+	return platform
+}
+
+// manifestBlob encodes the list, setting its media type and digest to match.
+// The images must all have Docker manifests, making a Docker manifest list,
+// or all have OCI manifests, making an OCI image index.
+func (l *ManifestList) manifestBlob() ([]byte, error) {
+	l.MediaType = ""
+	entries := make([]manifestListEntry, 0, len(l.Members))
+	for _, member := range l.Members {
+		var listType string
+		switch member.MediaType {
+		case manifest.DockerV2Schema2MediaType:
+			listType = manifest.DockerV2ListMediaType
+		case ociv1.MediaTypeImageManifest:
+			listType = ociv1.MediaTypeImageIndex
+		default:
+			return nil, errors.Errorf("image %s has a manifest of type %q, which cannot be included in a manifest list", member.ImageID, member.MediaType)
+		}
+		if l.MediaType == "" {
+			l.MediaType = listType
+		} else if l.MediaType != listType {
+			return nil, errors.Errorf("images in manifest list %s mix Docker and OCI manifests; convert them to one format first", l.Name)
+		}
+		var entry manifestListEntry
+		entry.MediaType = member.MediaType
+		entry.Size = member.Size
+		entry.Digest = member.Digest
+		entry.Platform.OS = member.OS
+		entry.Platform.Architecture = member.Architecture
+		entry.Platform.Variant = member.Variant
+		entries = append(entries, entry)
+	}
+
+	listBlob, err := json.Marshal(struct {
+		SchemaVersion int                 `json:"schemaVersion"`
+		MediaType     string              `json:"mediaType"`
+		Manifests     []manifestListEntry `json:"manifests"`
+	}{
+		SchemaVersion: 2,
+		MediaType:     l.MediaType,
+		Manifests:     entries,
+	})
+	if err != nil {
+		return nil, err
+	}
+	l.Digest = digest.FromBytes(listBlob)
+	return listBlob, nil
+}
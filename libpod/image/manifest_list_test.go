@@ -0,0 +1,162 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/containers/image/manifest"
+	"github.com/containers/storage"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+// createPlatformImage creates an image with no layers whose configuration
+// records the given platform
+func createPlatformImage(t *testing.T, ir *Runtime, name, manifestType, os, arch, variant string) {
+	config := []byte(fmt.Sprintf(`{"architecture":%q,"os":%q,"variant":%q,"config":{},"rootfs":{"type":"layers","diff_ids":[]}}`, arch, os, variant))
+	configDigest := digest.FromBytes(config)
+	var imgManifest []byte
+	if manifestType == ociv1.MediaTypeImageManifest {
+		// OCI manifests are only recognized as such if they list layers
+		imgManifest = []byte(fmt.Sprintf(`{"schemaVersion":2,"config":{"mediaType":%q,"size":%d,"digest":%q},"layers":[{"mediaType":%q,"size":1,"digest":%q}]}`, ociv1.MediaTypeImageConfig, len(config), configDigest, ociv1.MediaTypeImageLayerGzip, digest.FromString(name)))
+	} else {
+		imgManifest = []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":%q,"config":{"mediaType":%q,"size":%d,"digest":%q},"layers":[]}`, manifestType, manifest.DockerV2Schema2ConfigMediaType, len(config), configDigest))
+	}
+	img, err := ir.store.CreateImage("", []string{name}, "", "", &storage.ImageOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, ir.store.SetImageBigData(img.ID, "manifest", imgManifest))
+	assert.NoError(t, ir.store.SetImageBigData(img.ID, configDigest.String(), config))
+}
+
+func TestRuntime_CreateManifestList(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:   workdir,
+		GraphRoot: workdir,
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	createPlatformImage(t, ir, "docker.io/library/app:amd64", manifest.DockerV2Schema2MediaType, "linux", "amd64", "")
+	createPlatformImage(t, ir, "docker.io/library/app:arm", manifest.DockerV2Schema2MediaType, "linux", "arm", "v7")
+	createPlatformImage(t, ir, "docker.io/library/app:arm-again", manifest.DockerV2Schema2MediaType, "linux", "arm", "v7")
+	createPlatformImage(t, ir, "docker.io/library/app:noarch", manifest.DockerV2Schema2MediaType, "linux", "", "")
+	createPlatformImage(t, ir, "docker.io/library/app:oci-amd64", ociv1.MediaTypeImageManifest, "linux", "amd64", "")
+	createPlatformImage(t, ir, "docker.io/library/app:oci-ppc64le", ociv1.MediaTypeImageManifest, "linux", "ppc64le", "")
+
+	list, err := ir.CreateManifestList(ctx, "app", []string{"app:amd64", "app:arm"})
+	assert.NoError(t, err)
+	assert.Equal(t, "docker.io/library/app:latest", list.Name)
+	assert.Equal(t, manifest.DockerV2ListMediaType, list.MediaType)
+	assert.Len(t, list.Members, 2)
+	assert.Equal(t, "amd64", list.Members[0].Architecture)
+	assert.Equal(t, "v7", list.Members[1].Variant)
+
+	// The list is stored and refers to the manifests of the images
+	stored, err := ir.LookupManifestList("docker.io/library/app:latest")
+	assert.NoError(t, err)
+	assert.Equal(t, list, stored)
+	listBlob, err := stored.manifestBlob()
+	assert.NoError(t, err)
+	assert.Equal(t, list.Digest, digest.FromBytes(listBlob))
+	var platforms platformList
+	assert.NoError(t, json.Unmarshal(listBlob, &platforms))
+	assert.Len(t, platforms.Manifests, 2)
+	assert.Equal(t, list.Members[1].Digest, platforms.Manifests[1].Digest)
+	assert.Equal(t, "arm", platforms.Manifests[1].Platform.Architecture)
+	assert.Equal(t, manifest.DockerV2ListMediaType, manifest.GuessMIMEType(listBlob))
+
+	// OCI images make an OCI image index, and cannot be mixed with Docker
+	// images
+	index, err := ir.CreateManifestList(ctx, "index", []string{"app:oci-amd64", "app:oci-ppc64le"})
+	assert.NoError(t, err)
+	assert.Equal(t, ociv1.MediaTypeImageIndex, index.MediaType)
+	indexBlob, err := index.manifestBlob()
+	assert.NoError(t, err)
+	assert.Equal(t, ociv1.MediaTypeImageIndex, manifest.GuessMIMEType(indexBlob))
+	_, err = ir.CreateManifestList(ctx, "mixed", []string{"app:arm", "app:oci-ppc64le"})
+	assert.Error(t, err)
+
+	// Duplicate and missing platforms are rejected
+	_, err = ir.CreateManifestList(ctx, "dup", []string{"app:amd64", "app:arm", "app:arm-again"})
+	assert.Error(t, err)
+	_, err = ir.CreateManifestList(ctx, "noarch", []string{"app:noarch"})
+	assert.Error(t, err)
+	_, err = ir.CreateManifestList(ctx, "empty", nil)
+	assert.Error(t, err)
+	_, err = ir.LookupManifestList("dup")
+	assert.Error(t, err)
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
+
+func TestRuntime_EditManifestList(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:   workdir,
+		GraphRoot: workdir,
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	createPlatformImage(t, ir, "docker.io/library/app:amd64", manifest.DockerV2Schema2MediaType, "linux", "amd64", "")
+	createPlatformImage(t, ir, "docker.io/library/app:arm64", manifest.DockerV2Schema2MediaType, "linux", "arm64", "")
+	createPlatformImage(t, ir, "docker.io/library/app:arm64-again", manifest.DockerV2Schema2MediaType, "linux", "arm64", "")
+	createPlatformImage(t, ir, "docker.io/library/app:noarch", manifest.DockerV2Schema2MediaType, "", "", "")
+	createPlatformImage(t, ir, "docker.io/library/app:oci", ociv1.MediaTypeImageManifest, "linux", "ppc64le", "")
+
+	created, err := ir.CreateManifestList(ctx, "app", []string{"app:amd64"})
+	assert.NoError(t, err)
+
+	// Add an arm64 image
+	added, err := ir.AddToManifestList(ctx, "app", "app:arm64")
+	assert.NoError(t, err)
+	assert.Len(t, added.Members, 2)
+	assert.NotEqual(t, created.Digest, added.Digest)
+	stored, err := ir.LookupManifestList("app")
+	assert.NoError(t, err)
+	assert.Equal(t, added, stored)
+	arm64 := stored.Members[1]
+	assert.Equal(t, "arm64", arm64.Architecture)
+
+	// Images for a platform already in the list, without a platform, or
+	// of another format are rejected, leaving the list unchanged
+	_, err = ir.AddToManifestList(ctx, "app", "app:arm64-again")
+	assert.Error(t, err)
+	_, err = ir.AddToManifestList(ctx, "app", "app:noarch")
+	assert.Error(t, err)
+	_, err = ir.AddToManifestList(ctx, "app", "app:oci")
+	assert.Error(t, err)
+	stored, err = ir.LookupManifestList("app")
+	assert.NoError(t, err)
+	assert.Equal(t, added, stored)
+
+	// Remove the arm64 image again
+	removed, err := ir.RemoveFromManifestList("app", arm64.Digest.String())
+	assert.NoError(t, err)
+	assert.Equal(t, created, removed)
+	stored, err = ir.LookupManifestList("app")
+	assert.NoError(t, err)
+	assert.Equal(t, created, stored)
+
+	// Digests not in the list, and the last image, cannot be removed
+	_, err = ir.RemoveFromManifestList("app", arm64.Digest.String())
+	assert.Error(t, err)
+	_, err = ir.RemoveFromManifestList("app", "not-a-digest")
+	assert.Error(t, err)
+	_, err = ir.RemoveFromManifestList("app", created.Members[0].Digest.String())
+	assert.Error(t, err)
+	_, err = ir.AddToManifestList(ctx, "missing", "app:arm64")
+	assert.Error(t, err)
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
@@ -0,0 +1,63 @@
+package image
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/projectatomic/libpod/pkg/util"
+)
+
+// metadataBigDataKey is the key under which libpod stores its own metadata of
+// an image in storage
+const metadataBigDataKey = "libpod-metadata"
+
+// LocalMetadata returns the local metadata set on the image with
+// SetLocalMetadata
+func (i *Image) LocalMetadata() (map[string]string, error) {
+	if err := i.reloadImage(); err != nil {
+		return nil, err
+	}
+	metadata := make(map[string]string)
+	if !util.StringInSlice(metadataBigDataKey, i.image.BigDataNames) {
+		return metadata, nil
+	}
+	blob, err := i.imageruntime.store.ImageBigData(i.ID(), metadataBigDataKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read local metadata of image %s", i.ID())
+	}
+	if err := json.Unmarshal(blob, &metadata); err != nil {
+		return nil, errors.Wrapf(err, "unable to decode local metadata of image %s", i.ID())
+	}
+	return metadata, nil
+}
+
+// SetLocalMetadata sets local metadata on the image, such as when it was last
+// scanned.  Unlike labels, local metadata is not part of the image's
+// configuration, so setting it does not change the image's ID, and it is
+// never pushed.  It is kept in storage alongside the image until the image is
+// removed.  The given keys are set, replacing their previous values; keys
+// given an empty value are removed.  Other keys are left as they were.
+func (i *Image) SetLocalMetadata(metadata map[string]string) error {
+	current, err := i.LocalMetadata()
+	if err != nil {
+		return err
+	}
+	for key, value := range metadata {
+		if key == "" {
+			return errors.Errorf("metadata keys must not be empty")
+		}
+		if value == "" {
+			delete(current, key)
+		} else {
+			current[key] = value
+		}
+	}
+	blob, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	if err := i.imageruntime.store.SetImageBigData(i.ID(), metadataBigDataKey, blob); err != nil {
+		return errors.Wrapf(err, "unable to store local metadata of image %s", i.ID())
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/containers/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImage_LocalMetadata(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:   workdir,
+		GraphRoot: workdir,
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+
+	_, err = ir.store.CreateImage("", []string{"docker.io/library/annotated:latest"}, "", "", &storage.ImageOptions{})
+	assert.NoError(t, err)
+	annotated, err := ir.NewFromLocal("annotated")
+	assert.NoError(t, err)
+	id := annotated.ID()
+
+	metadata, err := annotated.LocalMetadata()
+	assert.NoError(t, err)
+	assert.Empty(t, metadata)
+
+	assert.NoError(t, annotated.SetLocalMetadata(map[string]string{"scanned": "2018-06", "owner": "web"}))
+	assert.NoError(t, annotated.SetLocalMetadata(map[string]string{"scanned": "2018-07", "owner": ""}))
+	assert.Error(t, annotated.SetLocalMetadata(map[string]string{"": "x"}))
+	assert.Equal(t, id, annotated.ID())
+
+	// The metadata survives restarting the runtime
+	assert.NoError(t, ir.Shutdown(false))
+	ir, err = NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+	annotated, err = ir.NewFromLocal("annotated")
+	assert.NoError(t, err)
+	assert.Equal(t, id, annotated.ID())
+	metadata, err = annotated.LocalMetadata()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"scanned": "2018-07"}, metadata)
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
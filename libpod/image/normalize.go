@@ -0,0 +1,61 @@
+package image
+
+import (
+	"strings"
+
+	"github.com/containers/image/docker/reference"
+	"github.com/pkg/errors"
+	"github.com/projectatomic/libpod/pkg/registries"
+)
+
+// NormalizeImageReference returns the fully qualified references which pulling
+// the given name would try, in the order they would be tried.  A name which
+// includes a registry has a single reference; a short name has one for each of
+// the search registries in registries.conf.  References without a tag or
+// digest get the latest tag.  Neither the registries nor local storage are
+// consulted, and registries.conf is only read for short names.
+func NormalizeImageReference(name string) ([]string, error) {
+	decomposedImage, err := decompose(strings.TrimPrefix(name, DockerTransport))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid image name %q", name)
+	}
+	var searchRegistries []string
+	if !decomposedImage.hasRegistry {
+		searchRegistries, err = registries.GetRegistries()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return normalizeImageReference(name, searchRegistries)
+}
+
+// normalizeImageReference returns the fully qualified references for a name
+// given the search registries
+func normalizeImageReference(name string, searchRegistries []string) ([]string, error) {
+	name = strings.TrimPrefix(name, DockerTransport)
+	decomposedImage, err := decompose(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid image name %q", name)
+	}
+
+	candidates := []string{name}
+	if !decomposedImage.hasRegistry {
+		if len(searchRegistries) == 0 {
+			return nil, errors.Errorf("%q is a short name and no search registries are defined", name)
+		}
+		candidates = nil
+		for _, registry := range searchRegistries {
+			candidates = append(candidates, registry+"/"+name)
+		}
+	}
+
+	var references []string
+	for _, candidate := range candidates {
+		named, err := reference.ParseNormalizedNamed(candidate)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid image name %q", candidate)
+		}
+		references = append(references, reference.TagNameOnly(named).String())
+	}
+	return references, nil
+}
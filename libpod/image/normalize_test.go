@@ -0,0 +1,41 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeImageReference(t *testing.T) {
+	const digest = "sha256:7df6db5aa61ae9480f52f0b3a06a140ab98d427f86d8d5de0bedab9b8df6b1c0"
+	search := []string{"docker.io", "registry.example.com"}
+
+	for name, expected := range map[string][]string{
+		// Bare names
+		"nginx": {"docker.io/library/nginx:latest", "registry.example.com/nginx:latest"},
+		// As when pulling, a name with several components is resolved
+		// on docker.io, rather than in the search registries
+		"library/nginx": {"docker.io/library/nginx:latest"},
+		// Names with a tag
+		"nginx:1.15": {"docker.io/library/nginx:1.15", "registry.example.com/nginx:1.15"},
+		// Names with a registry
+		"quay.io/app/web":              {"quay.io/app/web:latest"},
+		"localhost:5000/web:v2":        {"localhost:5000/web:v2"},
+		"docker://quay.io/app/web:1.0": {"quay.io/app/web:1.0"},
+		// Names with a digest
+		"quay.io/app/web@" + digest: {"quay.io/app/web@" + digest},
+		"nginx@" + digest:           {"docker.io/library/nginx@" + digest, "registry.example.com/nginx@" + digest},
+	} {
+		references, err := normalizeImageReference(name, search)
+		assert.NoError(t, err, name)
+		assert.Equal(t, expected, references, name)
+	}
+
+	_, err := normalizeImageReference("nginx", nil)
+	assert.Error(t, err)
+	references, err := normalizeImageReference("quay.io/app/web", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"quay.io/app/web:latest"}, references)
+	_, err = normalizeImageReference("Invalid Name", search)
+	assert.Error(t, err)
+}
@@ -0,0 +1,115 @@
+package image
+
+import (
+	"context"
+	"sort"
+
+	"github.com/containers/image/docker/reference"
+	"github.com/pkg/errors"
+)
+
+// PruneReport describes the images pruned from a repository
+type PruneReport struct {
+	// Removed holds the IDs of the removed images
+	Removed []string
+	// Size is the number of bytes reclaimed by removing them
+	Size uint64
+}
+
+// PruneImagesKeepRecent groups the images matching the given filters by
+// repository and removes all but the keep most recently created images of
+// each repository.  An image tagged in more than one repository is only
+// removed if it is not among the most recent of any of them, and images used
+// by containers are never removed.  Dangling images belong to no repository
+// and are left alone.  The returned reports are keyed by repository; an image
+// removed from several repositories is reported under the first of them only,
+// so reclaimed space is not counted twice.
+func (ir *Runtime) PruneImagesKeepRecent(ctx context.Context, keep int, filters []string) (map[string]*PruneReport, error) {
+	if keep < 0 {
+		return nil, errors.Errorf("number of images to keep must not be negative, got %d", keep)
+	}
+	filterFuncs, err := ir.CreateFilters(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	images, err := ir.GetImages()
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get list of images")
+	}
+
+	repositories := make(map[string][]*Image)
+	for _, img := range FilterImages(images, filterFuncs) {
+		for _, repo := range imageRepositories(img) {
+			repositories[repo] = append(repositories[repo], img)
+		}
+	}
+
+	// Sort the repositories so that images tagged in more than one of them
+	// are always reported under the same one
+	repoNames := make([]string, 0, len(repositories))
+	for repo := range repositories {
+		repoNames = append(repoNames, repo)
+	}
+	sort.Strings(repoNames)
+
+	kept := make(map[string]bool)
+	for _, repo := range repoNames {
+		repoImages := repositories[repo]
+		sort.SliceStable(repoImages, func(i, j int) bool {
+			return repoImages[i].Created().After(repoImages[j].Created())
+		})
+		for i := 0; i < keep && i < len(repoImages); i++ {
+			kept[repoImages[i].ID()] = true
+		}
+	}
+
+	reports := make(map[string]*PruneReport)
+	removed := make(map[string]bool)
+	for _, repo := range repoNames {
+		for _, img := range repositories[repo] {
+			if kept[img.ID()] || removed[img.ID()] {
+				continue
+			}
+			containers, err := img.Containers()
+			if err != nil {
+				return reports, errors.Wrapf(err, "unable to get containers of image %s", img.ID())
+			}
+			if len(containers) > 0 {
+				continue
+			}
+			// Determine the size before the image is gone
+			size, _ := img.Size(ctx)
+			if err := img.Remove(false); err != nil {
+				return reports, errors.Wrapf(err, "unable to remove image %s", img.ID())
+			}
+			removed[img.ID()] = true
+			report, ok := reports[repo]
+			if !ok {
+				report = &PruneReport{}
+				reports[repo] = report
+			}
+			report.Removed = append(report.Removed, img.ID())
+			if size != nil {
+				report.Size += *size
+			}
+		}
+	}
+	return reports, nil
+}
+
+// imageRepositories returns the distinct repositories an image is tagged in
+func imageRepositories(img *Image) []string {
+	var repos []string
+	seen := make(map[string]bool)
+	for _, name := range img.Names() {
+		named, err := reference.ParseNormalizedNamed(name)
+		if err != nil {
+			continue
+		}
+		if !seen[named.Name()] {
+			seen[named.Name()] = true
+			repos = append(repos, named.Name())
+		}
+	}
+	return repos
+}
@@ -0,0 +1,56 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/containers/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneImagesKeepRecent(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:   workdir,
+		GraphRoot: workdir,
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+
+	// Five tags of one repository, each an hour newer than the last
+	created := time.Now().Add(-24 * time.Hour)
+	var ids []string
+	for i := 1; i <= 5; i++ {
+		img, err := ir.store.CreateImage("", []string{fmt.Sprintf("docker.io/library/app:v%d", i)}, "", "", &storage.ImageOptions{
+			CreationDate: created.Add(time.Duration(i) * time.Hour),
+		})
+		assert.NoError(t, err)
+		ids = append(ids, img.ID)
+	}
+	// A repository with a single image must be left alone
+	other, err := ir.store.CreateImage("", []string{"docker.io/library/other:latest"}, "", "", &storage.ImageOptions{CreationDate: created})
+	assert.NoError(t, err)
+
+	reports, err := ir.PruneImagesKeepRecent(context.Background(), 2, nil)
+	assert.NoError(t, err)
+	assert.Len(t, reports, 1)
+	assert.NotNil(t, reports["docker.io/library/app"])
+	// Images are removed newest first
+	assert.Equal(t, []string{ids[2], ids[1], ids[0]}, reports["docker.io/library/app"].Removed)
+
+	exist := ir.ImagesExist([]string{"app:v1", "app:v2", "app:v3", "app:v4", "app:v5", other.ID})
+	assert.Equal(t, map[string]bool{
+		"app:v1": false,
+		"app:v2": false,
+		"app:v3": false,
+		"app:v4": true,
+		"app:v5": true,
+		other.ID: true,
+	}, exist)
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
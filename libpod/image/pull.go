@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"strings"
 
@@ -13,12 +14,17 @@ import (
 	dockerarchive "github.com/containers/image/docker/archive"
 	"github.com/containers/image/docker/reference"
 	"github.com/containers/image/docker/tarfile"
+	"github.com/containers/image/manifest"
 	ociarchive "github.com/containers/image/oci/archive"
 	"github.com/containers/image/pkg/sysregistries"
 	is "github.com/containers/image/storage"
 	"github.com/containers/image/tarball"
 	"github.com/containers/image/transports/alltransports"
 	"github.com/containers/image/types"
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/distribution/registry/api/v2"
+	"github.com/docker/distribution/registry/client"
+	"github.com/opencontainers/go-digest"
 	"github.com/pkg/errors"
 	"github.com/projectatomic/libpod/pkg/registries"
 	"github.com/projectatomic/libpod/pkg/util"
@@ -46,6 +52,10 @@ var (
 	AtomicTransport = "atomic"
 	// DefaultTransport is a prefix that we apply to an image name
 	DefaultTransport = DockerTransport
+
+	// ErrRemoteImageNotFound indicates that a registry does not know the
+	// requested repository or tag
+	ErrRemoteImageNotFound = errors.New("image not found in registry")
 )
 
 type pullStruct struct {
@@ -214,7 +224,7 @@ func (i *Image) pullImage(ctx context.Context, writer io.Writer, authfile, signa
 		if writer != nil && (strings.HasPrefix(DockerTransport, imageInfo.srcRef.Transport().Name()) || imageInfo.srcRef.Transport().Name() == AtomicTransport) {
 			io.WriteString(writer, fmt.Sprintf("Trying to pull %s...", imageInfo.image))
 		}
-		if err = cp.Image(ctx, policyContext, imageInfo.dstRef, imageInfo.srcRef, copyOptions); err != nil {
+		if err = cp.Image(ctx, policyContext, imageInfo.dstRef, i.imageruntime.limitTransferRate(imageInfo.srcRef), copyOptions); err != nil {
 			if writer != nil {
 				io.WriteString(writer, "Failed\n")
 			}
@@ -307,3 +317,74 @@ func (i *Image) isShortName() (bool, error) {
 	}
 	return decomposedImage.hasRegistry, nil
 }
+
+// GetRemoteDigest returns the digest of the manifest the given name currently
+// resolves to in its registry, without pulling the image.  If the registry does
+// not know the repository or tag, the cause of the returned error is
+// ErrRemoteImageNotFound; any other error means the registry could not be
+// queried.
+func GetRemoteDigest(ctx context.Context, name, authfile string, dockerOptions *DockerRegistryOptions) (digest.Digest, error) {
+	src, err := openRegistrySource(ctx, name, authfile, dockerOptions)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	manifestBlob, _, err := getRemoteManifest(ctx, src, name, nil)
+	if err != nil {
+		return "", err
+	}
+	return manifest.Digest(manifestBlob)
+}
+
+// openRegistrySource connects to the registry holding the named image
+func openRegistrySource(ctx context.Context, name, authfile string, dockerOptions *DockerRegistryOptions) (types.ImageSource, error) {
+	srcRef, err := alltransports.ParseImageName(name)
+	if err != nil {
+		srcRef, err = alltransports.ParseImageName(DefaultTransport + name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing image name %q", name)
+		}
+	}
+	if !strings.HasPrefix(DockerTransport, srcRef.Transport().Name()) {
+		return nil, errors.Errorf("%q does not refer to an image in a registry", name)
+	}
+
+	sc := dockerOptions.GetSystemContext("", authfile, false, nil)
+	src, err := srcRef.NewImageSource(ctx, sc)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error connecting to registry for %q", name)
+	}
+	return src, nil
+}
+
+// getRemoteManifest reads a manifest of the named image from its registry,
+// mapping the registry's not found errors to ErrRemoteImageNotFound
+func getRemoteManifest(ctx context.Context, src types.ImageSource, name string, instanceDigest *digest.Digest) ([]byte, string, error) {
+	manifestBlob, manifestType, err := src.GetManifest(ctx, instanceDigest)
+	if err != nil {
+		if isRemoteNotFound(err) {
+			return nil, "", errors.Wrapf(ErrRemoteImageNotFound, "%s", name)
+		}
+		return nil, "", errors.Wrapf(err, "error reading manifest of %q from registry", name)
+	}
+	return manifestBlob, manifestType, nil
+}
+
+// isRemoteNotFound returns true if the error returned by a registry means the
+// requested repository or manifest does not exist
+func isRemoteNotFound(err error) bool {
+	switch e := errors.Cause(err).(type) {
+	case errcode.Errors:
+		for _, ec := range e {
+			if isRemoteNotFound(ec) {
+				return true
+			}
+		}
+	case errcode.Error:
+		return e.Code == v2.ErrorCodeManifestUnknown || e.Code == v2.ErrorCodeNameUnknown
+	case *client.UnexpectedHTTPResponseError:
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
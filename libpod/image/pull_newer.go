@@ -0,0 +1,71 @@
+package image
+
+import (
+	"context"
+	"io"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"github.com/projectatomic/libpod/pkg/util"
+)
+
+// PullIfNewer pulls the named image unless the local image of that name is
+// already the one the name refers to in its registry, which is checked by
+// comparing manifest digests without pulling.  If there is no local image of
+// that name, it is always pulled.  The resulting image is returned, along
+// with whether it was pulled.
+func (ir *Runtime) PullIfNewer(ctx context.Context, name, signaturePolicyPath, authfile string, writer io.Writer, dockeroptions *DockerRegistryOptions, signingoptions SigningOptions, forceSecure bool) (*Image, bool, error) {
+	pull := func() (*Image, error) {
+		return ir.New(ctx, name, signaturePolicyPath, authfile, writer, dockeroptions, signingoptions, true, forceSecure)
+	}
+	remoteDigests := func(ctx context.Context, remoteName string) ([]digest.Digest, error) {
+		return getRemoteDigests(ctx, remoteName, authfile, dockeroptions)
+	}
+	candidates, err := NormalizeImageReference(name)
+	if err != nil {
+		return nil, false, err
+	}
+	return ir.pullIfNewer(ctx, name, candidates, remoteDigests, pull)
+}
+
+// pullIfNewer pulls an image with pull unless the local image of that name has
+// one of the digests returned by remoteDigests.  The candidates are the fully
+// qualified references the name may refer to; the registry of the first one
+// naming the local image is queried.
+func (ir *Runtime) pullIfNewer(ctx context.Context, name string, candidates []string, remoteDigests remoteDigestsFunc, pull func() (*Image, error)) (*Image, bool, error) {
+	pullImage := func() (*Image, bool, error) {
+		img, err := pull()
+		if err != nil {
+			return nil, false, err
+		}
+		return img, true, nil
+	}
+
+	local, err := ir.NewFromLocal(name)
+	if err != nil || local.Digest() == "" {
+		return pullImage()
+	}
+	remoteName := ""
+	for _, candidate := range candidates {
+		if util.StringInSlice(candidate, local.Names()) {
+			remoteName = candidate
+			break
+		}
+	}
+	if remoteName == "" {
+		// The local image was not pulled under this name, so its
+		// digest says nothing about the registry's
+		return pullImage()
+	}
+
+	digests, err := remoteDigests(ctx, remoteName)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "unable to check whether %s is up to date", remoteName)
+	}
+	for _, d := range digests {
+		if d == local.Digest() {
+			return local, false, nil
+		}
+	}
+	return pullImage()
+}
@@ -0,0 +1,65 @@
+package image
+
+import (
+	"context"
+	"testing"
+
+	"github.com/containers/storage"
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImage_PullIfNewer(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:   workdir,
+		GraphRoot: workdir,
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	localDigest := digest.FromString("local manifest")
+	img, err := ir.store.CreateImage("", []string{"docker.io/library/pulled:latest"}, "", "", &storage.ImageOptions{Digest: localDigest})
+	assert.NoError(t, err)
+	candidates := []string{"docker.io/library/pulled:latest"}
+
+	pulls := 0
+	pull := func() (*Image, error) {
+		pulls++
+		return ir.NewFromLocal(img.ID)
+	}
+	remoteDigest := localDigest
+	checked := ""
+	remoteDigests := func(ctx context.Context, name string) ([]digest.Digest, error) {
+		checked = name
+		return []digest.Digest{remoteDigest}, nil
+	}
+
+	// The local and remote digests match, so nothing is pulled
+	current, pulled, err := ir.pullIfNewer(ctx, "pulled", candidates, remoteDigests, pull)
+	assert.NoError(t, err)
+	assert.False(t, pulled)
+	assert.Equal(t, 0, pulls)
+	assert.Equal(t, img.ID, current.ID())
+	assert.Equal(t, "docker.io/library/pulled:latest", checked)
+
+	// The tag has moved in the registry
+	remoteDigest = digest.FromString("remote manifest")
+	_, pulled, err = ir.pullIfNewer(ctx, "pulled", candidates, remoteDigests, pull)
+	assert.NoError(t, err)
+	assert.True(t, pulled)
+	assert.Equal(t, 1, pulls)
+
+	// There is no local image, so the registry is not checked
+	checked = ""
+	_, pulled, err = ir.pullIfNewer(ctx, "absent", []string{"docker.io/library/absent:latest"}, remoteDigests, pull)
+	assert.NoError(t, err)
+	assert.True(t, pulled)
+	assert.Equal(t, 2, pulls)
+	assert.Empty(t, checked)
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
@@ -0,0 +1,101 @@
+package image
+
+import (
+	"context"
+	"io"
+
+	"github.com/containers/image/types"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+// SetTransferRateLimit limits the combined rate at which images are pulled,
+// pushed and exported by the runtime to bytesPerSec bytes per second.  A
+// limit of 0 removes the limit.
+func (ir *Runtime) SetTransferRateLimit(bytesPerSec int64) error {
+	if bytesPerSec < 0 {
+		return errors.Errorf("invalid transfer rate limit %d, must not be negative", bytesPerSec)
+	}
+	ir.transferLock.Lock()
+	defer ir.transferLock.Unlock()
+	if bytesPerSec == 0 {
+		ir.transferLimiter = nil
+		return nil
+	}
+	// Allow at most a second's worth of data to be read at once
+	burst := bytesPerSec
+	if burst > maxTransferBurst {
+		burst = maxTransferBurst
+	}
+	ir.transferLimiter = rate.NewLimiter(rate.Limit(bytesPerSec), int(burst))
+	return nil
+}
+
+// maxTransferBurst caps the amount of data read from a rate-limited blob at
+// once
+const maxTransferBurst = 1 << 30
+
+// limitTransferRate returns a reference whose blobs are read no faster than
+// the runtime's transfer rate limit allows, or the reference itself if there
+// is no limit
+func (ir *Runtime) limitTransferRate(ref types.ImageReference) types.ImageReference {
+	ir.transferLock.Lock()
+	defer ir.transferLock.Unlock()
+	if ir.transferLimiter == nil {
+		return ref
+	}
+	return &rateLimitedReference{ImageReference: ref, limiter: ir.transferLimiter}
+}
+
+// rateLimitedReference is an image reference whose image sources are rate
+// limited
+type rateLimitedReference struct {
+	types.ImageReference
+	limiter *rate.Limiter
+}
+
+// NewImageSource returns a rate-limited image source for the reference
+func (r *rateLimitedReference) NewImageSource(ctx context.Context, sys *types.SystemContext) (types.ImageSource, error) {
+	src, err := r.ImageReference.NewImageSource(ctx, sys)
+	if err != nil {
+		return nil, err
+	}
+	return &rateLimitedSource{ImageSource: src, limiter: r.limiter}, nil
+}
+
+// rateLimitedSource is an image source whose blobs are rate limited
+type rateLimitedSource struct {
+	types.ImageSource
+	limiter *rate.Limiter
+}
+
+// GetBlob returns a stream for the blob which is read no faster than the
+// limiter allows
+func (s *rateLimitedSource) GetBlob(ctx context.Context, info types.BlobInfo) (io.ReadCloser, int64, error) {
+	blob, size, err := s.ImageSource.GetBlob(ctx, info)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &rateLimitedReader{ReadCloser: blob, ctx: ctx, limiter: s.limiter}, size, nil
+}
+
+// rateLimitedReader waits for the limiter before returning each read
+type rateLimitedReader struct {
+	io.ReadCloser
+	ctx     context.Context
+	limiter *rate.Limiter
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	// Never read more than the limiter can allow at once
+	if len(p) > r.limiter.Burst() {
+		p = p[:r.limiter.Burst()]
+	}
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
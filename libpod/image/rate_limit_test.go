@@ -0,0 +1,74 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/containers/storage"
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuntime_SetTransferRateLimit(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:         workdir,
+		GraphRoot:       workdir,
+		GraphDriverName: "vfs",
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	// A layer of 384KiB, which takes two seconds to push at 128KiB/s after
+	// the initial burst of 128KiB
+	content := make([]byte, 384*1024)
+	rand.New(rand.NewSource(0)).Read(content)
+	layer, _, err := ir.store.PutLayer("", "", nil, "", false, nil, bytes.NewReader(layerTar(t, "file", string(content))))
+	assert.NoError(t, err)
+	config := []byte(fmt.Sprintf(`{"architecture":"amd64","os":"linux","config":{},"rootfs":{"type":"layers","diff_ids":[%q]}}`, layer.UncompressedDigest))
+	configDigest := digest.FromBytes(config)
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{"mediaType":"application/vnd.docker.container.image.v1+json","size":%d,"digest":%q},"layers":[{"mediaType":"application/vnd.docker.image.rootfs.diff.tar","size":%d,"digest":%q}]}`, len(config), configDigest, layer.UncompressedSize, layer.UncompressedDigest))
+	img, err := ir.store.CreateImage(configDigest.Hex(), []string{"docker.io/library/limited:latest"}, layer.ID, "", &storage.ImageOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, ir.store.SetImageBigData(img.ID, "manifest", manifest))
+	assert.NoError(t, ir.store.SetImageBigData(img.ID, configDigest.String(), config))
+	limited, err := ir.NewFromLocal("limited")
+	assert.NoError(t, err)
+
+	policy := filepath.Join(workdir, "policy.json")
+	assert.NoError(t, ioutil.WriteFile(policy, []byte(`{"default":[{"type":"insecureAcceptAnything"}]}`), 0644))
+	registries := filepath.Join(workdir, "registries.conf")
+	assert.NoError(t, ioutil.WriteFile(registries, []byte("[registries.search]\nregistries = []\n"), 0644))
+	defer os.Setenv("REGISTRIES_CONFIG_PATH", os.Getenv("REGISTRIES_CONFIG_PATH"))
+	os.Setenv("REGISTRIES_CONFIG_PATH", registries)
+
+	push := func(name string) time.Duration {
+		start := time.Now()
+		assert.NoError(t, limited.PushImage(ctx, "dir:"+filepath.Join(workdir, name), "", "", policy, nil, false, SigningOptions{}, &DockerRegistryOptions{}, false, nil))
+		return time.Since(start)
+	}
+
+	assert.NoError(t, ir.SetTransferRateLimit(128*1024))
+	elapsed := push("limited")
+	assert.True(t, elapsed >= 1800*time.Millisecond, "push took %s, expected at least two seconds", elapsed)
+	assert.True(t, elapsed < 5*time.Second, "push took %s, expected about two seconds", elapsed)
+
+	// 0 removes the limit
+	assert.NoError(t, ir.SetTransferRateLimit(0))
+	elapsed = push("unlimited")
+	assert.True(t, elapsed < 900*time.Millisecond, "push took %s, expected no limit", elapsed)
+
+	assert.Error(t, ir.SetTransferRateLimit(-1))
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
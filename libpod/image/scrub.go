@@ -0,0 +1,24 @@
+package image
+
+import (
+	"context"
+)
+
+// ScrubHistory creates a new image named newName which has the same layers as
+// the image, but whose history no longer records the commands that created
+// each layer.  Unlike squashing, the layers and their digests are preserved;
+// only the configuration changes, so the new image has a different config
+// digest and therefore a different ID.  Signatures of the image do not carry
+// over, as they cover the original manifest.
+func (i *Image) ScrubHistory(ctx context.Context, newName string) (*Image, error) {
+	return i.rewriteConfig(ctx, newName, "scrub history of", func(config map[string]interface{}) error {
+		if history, ok := config["history"].([]interface{}); ok {
+			for _, entry := range history {
+				if entry, ok := entry.(map[string]interface{}); ok {
+					delete(entry, "created_by")
+				}
+			}
+		}
+		return nil
+	})
+}
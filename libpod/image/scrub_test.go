@@ -0,0 +1,57 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/containers/storage"
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImage_ScrubHistory(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:   workdir,
+		GraphRoot: workdir,
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+
+	layer1 := digest.FromString("layer1")
+	layer2 := digest.FromString("layer2")
+	config := []byte(fmt.Sprintf(`{"architecture":"amd64","os":"linux","config":{},"rootfs":{"type":"layers","diff_ids":[%q,%q]},"history":[{"created":"2018-06-01T00:00:00Z","created_by":"/bin/sh -c #(nop) ADD file:/home/builder/secret/rootfs.tar in /"},{"created":"2018-06-01T00:00:01Z","created_by":"/bin/sh -c make install TOKEN=hunter2","comment":"build"}]}`, layer1, layer2))
+	configDigest := digest.FromBytes(config)
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{"mediaType":"application/vnd.docker.container.image.v1+json","size":%d,"digest":%q},"layers":[{"mediaType":"application/vnd.docker.image.rootfs.diff.tar.gzip","size":1,"digest":%q},{"mediaType":"application/vnd.docker.image.rootfs.diff.tar.gzip","size":1,"digest":%q}]}`, len(config), configDigest, layer1, layer2))
+	img, err := ir.store.CreateImage(configDigest.Hex(), []string{"docker.io/library/built:latest"}, "", "", &storage.ImageOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, ir.store.SetImageBigData(img.ID, "manifest", manifest))
+	assert.NoError(t, ir.store.SetImageBigData(img.ID, configDigest.String(), config))
+
+	built, err := ir.NewFromLocal("built")
+	assert.NoError(t, err)
+	scrubbed, err := built.ScrubHistory(context.Background(), "scrubbed")
+	assert.NoError(t, err)
+	assert.NotEqual(t, built.ID(), scrubbed.ID())
+	assert.Equal(t, []string{"docker.io/library/scrubbed:latest"}, scrubbed.Names())
+
+	history, layers, err := scrubbed.History(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	for _, entry := range history {
+		assert.Empty(t, entry.CreatedBy)
+	}
+	assert.Equal(t, "build", history[1].Comment)
+
+	_, originalLayers, err := built.History(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, len(originalLayers), len(layers))
+	for n := range layers {
+		assert.Equal(t, originalLayers[n].Digest, layers[n].Digest)
+	}
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
@@ -0,0 +1,117 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/containers/image/manifest"
+	"github.com/containers/image/signature"
+	is "github.com/containers/image/storage"
+	"github.com/containers/image/types"
+	"github.com/pkg/errors"
+)
+
+// signaturesBigDataKey is the key under which containers/image stores the
+// signatures of an image in storage
+const signaturesBigDataKey = "signatures"
+
+// Signatures returns the signatures stored with the image
+func (i *Image) Signatures(ctx context.Context) ([][]byte, error) {
+	storeRef, err := is.Transport.ParseStoreReference(i.imageruntime.store, i.ID())
+	if err != nil {
+		return nil, err
+	}
+	src, err := storeRef.NewImageSource(ctx, &types.SystemContext{})
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	return src.GetSignatures(ctx, nil)
+}
+
+// AddSignatures stores externally provided signatures with the image, so that
+// it can satisfy a signature policy requiring them.  Each signature must be
+// for the image's manifest, or no signature is stored.  Signatures the image
+// already has are not stored twice.
+func (i *Image) AddSignatures(ctx context.Context, signatures [][]byte) error {
+	imgManifest, _, err := i.Manifest(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "unable to get manifest of image %s", i.ID())
+	}
+	for n, sig := range signatures {
+		info, err := signature.GetUntrustedSignatureInformationWithoutVerifying(sig)
+		if err != nil {
+			return errors.Wrapf(err, "unable to parse signature %d", n)
+		}
+		matches, err := manifest.MatchesDigest(imgManifest, info.UntrustedDockerManifestDigest)
+		if err != nil {
+			return errors.Wrapf(err, "unable to compare signature %d with the manifest of image %s", n, i.ID())
+		}
+		if !matches {
+			return errors.Errorf("signature %d is for manifest %s, which is not the manifest of image %s", n, info.UntrustedDockerManifestDigest, i.ID())
+		}
+	}
+
+	existing, err := i.Signatures(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "unable to get signatures of image %s", i.ID())
+	}
+	var sizes []int
+	var blob []byte
+	for _, sig := range existing {
+		sizes = append(sizes, len(sig))
+		blob = append(blob, sig...)
+	}
+	added := false
+	for _, sig := range signatures {
+		if containsSignature(existing, sig) {
+			continue
+		}
+		existing = append(existing, sig)
+		sizes = append(sizes, len(sig))
+		blob = append(blob, sig...)
+		added = true
+	}
+	if !added {
+		return nil
+	}
+
+	// The signature sizes are kept in the image's metadata alongside
+	// whatever else containers/image recorded there, which must be kept
+	metadata := make(map[string]*json.RawMessage)
+	if i.image.Metadata != "" {
+		if err := json.Unmarshal([]byte(i.image.Metadata), &metadata); err != nil {
+			return errors.Wrapf(err, "unable to decode metadata of image %s", i.ID())
+		}
+	}
+	sizesJSON, err := json.Marshal(sizes)
+	if err != nil {
+		return err
+	}
+	rawSizes := json.RawMessage(sizesJSON)
+	metadata["signature-sizes"] = &rawSizes
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	if err := i.imageruntime.store.SetImageBigData(i.ID(), signaturesBigDataKey, blob); err != nil {
+		return errors.Wrapf(err, "unable to store signatures of image %s", i.ID())
+	}
+	if err := i.imageruntime.store.SetMetadata(i.ID(), string(metadataJSON)); err != nil {
+		return errors.Wrapf(err, "unable to store metadata of image %s", i.ID())
+	}
+	i.image.Metadata = string(metadataJSON)
+	return nil
+}
+
+// containsSignature returns true if sig is among signatures
+func containsSignature(signatures [][]byte, sig []byte) bool {
+	for _, s := range signatures {
+		if bytes.Equal(s, sig) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/containers/image/manifest"
+	"github.com/containers/storage"
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+const testManifest = `{
+   "schemaVersion": 2,
+   "mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+   "config": {
+      "mediaType": "application/vnd.docker.container.image.v1+json",
+      "size": 1,
+      "digest": "sha256:6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d"
+   },
+   "layers": []
+}`
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// signManifestDigest returns a signature of an image with the given manifest
+// digest, in the format produced by containers/image
+func signManifestDigest(t *testing.T, signer *openpgp.Entity, manifestDigest digest.Digest) []byte {
+	content := []byte(fmt.Sprintf(`{"critical":{"type":"atomic container signature","image":{"docker-manifest-digest":%q},"identity":{"docker-reference":"docker.io/library/signed:latest"}},"optional":{}}`, manifestDigest))
+
+	var buf bytes.Buffer
+	ops := &packet.OnePassSignature{
+		SigType:    packet.SigTypeBinary,
+		Hash:       crypto.SHA256,
+		PubKeyAlgo: signer.PrivateKey.PubKeyAlgo,
+		KeyId:      signer.PrivateKey.KeyId,
+		IsLast:     true,
+	}
+	assert.NoError(t, ops.Serialize(&buf))
+	literal, err := packet.SerializeLiteral(nopWriteCloser{&buf}, true, "", 0)
+	assert.NoError(t, err)
+	_, err = literal.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, literal.Close())
+
+	sig := &packet.Signature{
+		SigType:      packet.SigTypeBinary,
+		PubKeyAlgo:   signer.PrivateKey.PubKeyAlgo,
+		Hash:         crypto.SHA256,
+		CreationTime: time.Now(),
+		IssuerKeyId:  &signer.PrivateKey.KeyId,
+	}
+	h := crypto.SHA256.New()
+	h.Write(content)
+	assert.NoError(t, sig.Sign(h, signer.PrivateKey, nil))
+	assert.NoError(t, sig.Serialize(&buf))
+	return buf.Bytes()
+}
+
+func TestImage_AddSignatures(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:   workdir,
+		GraphRoot: workdir,
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+
+	img, err := ir.store.CreateImage("", []string{"docker.io/library/signed:latest"}, "", "", &storage.ImageOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, ir.store.SetImageBigData(img.ID, "manifest", []byte(testManifest)))
+	signed, err := ir.NewFromLocal("signed")
+	assert.NoError(t, err)
+
+	signer, err := openpgp.NewEntity("test", "", "test@example.com", nil)
+	assert.NoError(t, err)
+	manifestDigest, err := manifest.Digest([]byte(testManifest))
+	assert.NoError(t, err)
+	good := signManifestDigest(t, signer, manifestDigest)
+	bad := signManifestDigest(t, signer, digest.FromString("some other manifest"))
+
+	// A signature for another manifest is rejected, along with the good one
+	assert.Error(t, signed.AddSignatures(context.Background(), [][]byte{good, bad}))
+	sigs, err := signed.Signatures(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, sigs)
+
+	// Matching signatures are stored once
+	assert.NoError(t, signed.AddSignatures(context.Background(), [][]byte{good}))
+	assert.NoError(t, signed.AddSignatures(context.Background(), [][]byte{good}))
+	sigs, err = signed.Signatures(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{good}, sigs)
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
@@ -0,0 +1,146 @@
+package image
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/containers/storage"
+	"github.com/pkg/errors"
+	"github.com/projectatomic/libpod/libpod/driver"
+)
+
+// StorageInfo describes how an image is kept by the storage driver
+type StorageInfo struct {
+	// Driver is the name of the storage driver
+	Driver string
+	// DriverOptions are the options the storage driver was configured with
+	DriverOptions []string
+	// Layers are the layers of the image, base layer first
+	Layers []LayerStorageInfo
+	// Size is the total size of the files of all layers on disk, in bytes
+	Size int64
+}
+
+// LayerStorageInfo describes how a layer of an image is kept by the storage
+// driver
+type LayerStorageInfo struct {
+	// ID is the ID of the layer
+	ID string
+	// Location is the directory holding the files the layer adds, or
+	// empty if the driver does not keep layers in directories
+	Location string
+	// DriverData is the driver's metadata of the layer, such as the
+	// overlay lower and upper directories
+	DriverData map[string]string
+	// Size is the size of the files in Location on disk, in bytes, or the
+	// uncompressed size of the layer if it has no location
+	Size int64
+}
+
+// StorageInfo returns how the image is kept by the storage driver: the
+// driver's name and options, and where each layer of the image is on disk.
+// The size on disk is the size of the files in the layers' directories, which
+// can differ from the image's size, as drivers such as vfs store each layer
+// as a full copy of the file system.  An error is returned, rather than
+// partial information, if a layer of the image is missing from storage or
+// from disk.
+func (i *Image) StorageInfo() (*StorageInfo, error) {
+	if err := i.reloadImage(); err != nil {
+		return nil, err
+	}
+	store := i.imageruntime.store
+	driverName, err := driver.GetDriverName(store)
+	if err != nil {
+		return nil, err
+	}
+	info := &StorageInfo{
+		Driver:        driverName,
+		DriverOptions: store.GraphOptions(),
+		Layers:        []LayerStorageInfo{},
+	}
+
+	var chain []LayerStorageInfo
+	seen := make(map[string]bool)
+	for layerID := i.TopLayer(); layerID != ""; {
+		if seen[layerID] {
+			return nil, errors.Errorf("storage of image %s is inconsistent: layer %s is its own ancestor", i.ID(), layerID)
+		}
+		seen[layerID] = true
+		layer, err := store.Layer(layerID)
+		if err != nil {
+			if errors.Cause(err) == storage.ErrLayerUnknown {
+				return nil, errors.Errorf("storage of image %s is inconsistent: layer %s is missing", i.ID(), layerID)
+			}
+			return nil, errors.Wrapf(err, "unable to read layer %s of image %s", layerID, i.ID())
+		}
+		layerInfo, err := i.layerStorageInfo(layer, driverName)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, *layerInfo)
+		info.Size += layerInfo.Size
+		layerID = layer.Parent
+	}
+	for n := len(chain) - 1; n >= 0; n-- {
+		info.Layers = append(info.Layers, chain[n])
+	}
+	return info, nil
+}
+
+// layerStorageInfo returns where a layer of the image is kept by the driver
+func (i *Image) layerStorageInfo(layer *storage.Layer, driverName string) (*LayerStorageInfo, error) {
+	store := i.imageruntime.store
+	driverData, err := driver.GetDriverMetadata(store, layer.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "storage of image %s is inconsistent: unable to read driver data of layer %s", i.ID(), layer.ID)
+	}
+	layerInfo := &LayerStorageInfo{
+		ID:         layer.ID,
+		Location:   layerLocation(filepath.Join(store.GraphRoot(), driverName), driverName, layer.ID, driverData),
+		DriverData: driverData,
+		Size:       layer.UncompressedSize,
+	}
+	if layerInfo.Location != "" {
+		size, err := diskUsage(layerInfo.Location)
+		if err != nil {
+			if os.IsNotExist(errors.Cause(err)) {
+				return nil, errors.Errorf("storage of image %s is inconsistent: directory %s of layer %s is missing", i.ID(), layerInfo.Location, layer.ID)
+			}
+			return nil, errors.Wrapf(err, "unable to compute size of layer %s", layer.ID)
+		}
+		layerInfo.Size = size
+	}
+	return layerInfo, nil
+}
+
+// layerLocation returns the directory holding the files a layer adds, for the
+// drivers which keep layers in directories under their home
+func layerLocation(home, driverName, layerID string, driverData map[string]string) string {
+	if upperDir, ok := driverData["UpperDir"]; ok {
+		return upperDir
+	}
+	switch driverName {
+	case "vfs":
+		return filepath.Join(home, "dir", layerID)
+	case "aufs":
+		return filepath.Join(home, "diff", layerID)
+	case "btrfs":
+		return filepath.Join(home, "subvolumes", layerID)
+	}
+	return ""
+}
+
+// diskUsage returns the total size of the regular files in a directory tree
+func diskUsage(path string) (int64, error) {
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,64 @@
+package image
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containers/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImage_StorageInfo(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:         workdir,
+		GraphRoot:       workdir,
+		GraphDriverName: "vfs",
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+
+	base, _, err := ir.store.PutLayer("", "", nil, "", false, nil, bytes.NewReader(layerTar(t, "base", "base")))
+	assert.NoError(t, err)
+	top, _, err := ir.store.PutLayer("", base.ID, nil, "", false, nil, bytes.NewReader(layerTar(t, "top", "top")))
+	assert.NoError(t, err)
+	_, err = ir.store.CreateImage("", []string{"docker.io/library/layered:latest"}, top.ID, "", &storage.ImageOptions{})
+	assert.NoError(t, err)
+	layered, err := ir.NewFromLocal("layered")
+	assert.NoError(t, err)
+
+	info, err := layered.StorageInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, "vfs", info.Driver)
+	assert.Len(t, info.Layers, 2)
+	assert.Equal(t, base.ID, info.Layers[0].ID)
+	assert.Equal(t, filepath.Join(workdir, "vfs", "dir", base.ID), info.Layers[0].Location)
+	assert.Equal(t, top.ID, info.Layers[1].ID)
+	// vfs keeps a full copy of the file system for each layer, so the
+	// base layer's file takes space twice
+	assert.Equal(t, int64(len("base")), info.Layers[0].Size)
+	assert.Equal(t, int64(len("base")+len("top")), info.Layers[1].Size)
+	assert.Equal(t, int64(2*len("base")+len("top")), info.Size)
+
+	// Images without layers have no storage
+	_, err = ir.store.CreateImage("", []string{"docker.io/library/empty:latest"}, "", "", &storage.ImageOptions{})
+	assert.NoError(t, err)
+	empty, err := ir.NewFromLocal("empty")
+	assert.NoError(t, err)
+	info, err = empty.StorageInfo()
+	assert.NoError(t, err)
+	assert.Empty(t, info.Layers)
+	assert.Equal(t, int64(0), info.Size)
+
+	// A layer missing from disk is reported, rather than left out
+	assert.NoError(t, os.RemoveAll(filepath.Join(workdir, "vfs", "dir", base.ID)))
+	_, err = layered.StorageInfo()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "inconsistent")
+
+	// Shutdown the runtime and remove the temporary storage
+	cleanup(workdir, ir)
+}
@@ -0,0 +1,168 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/containers/image/docker/reference"
+	"github.com/containers/image/manifest"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// maxConcurrentUpdateChecks is the number of registries queried at once by
+// CheckImageUpdates
+const maxConcurrentUpdateChecks = 5
+
+// UpdateStatus describes whether a local image is current with its registry
+type UpdateStatus string
+
+const (
+	// UpdateStatusCurrent means the tag still refers to the local image
+	UpdateStatusCurrent UpdateStatus = "current"
+	// UpdateStatusOutdated means the tag refers to a different image in
+	// the registry
+	UpdateStatusOutdated UpdateStatus = "outdated"
+	// UpdateStatusUnknown means the registry could not be queried
+	UpdateStatusUnknown UpdateStatus = "unknown"
+)
+
+// ImageUpdate reports whether a tag of a local image has moved in its
+// registry
+type ImageUpdate struct {
+	// ID is the ID of the local image
+	ID string
+	// Name is the tag which was checked
+	Name string
+	// LocalDigest is the manifest digest of the local image
+	LocalDigest digest.Digest
+	// RemoteDigest is the manifest digest the tag refers to in the
+	// registry.  It is empty if the status is UpdateStatusUnknown.
+	RemoteDigest digest.Digest
+	Status       UpdateStatus
+	// Error explains why the status is UpdateStatusUnknown
+	Error error
+}
+
+// remoteDigestsFunc returns the digests of the manifests the named image
+// resolves to in its registry
+type remoteDigestsFunc func(ctx context.Context, name string) ([]digest.Digest, error)
+
+// CheckImageUpdates checks, for each tag of the local images which names a
+// registry, whether the tag still refers to the same image in the registry.
+// Only the tags which have moved, or which could not be checked, are
+// reported; a tag which cannot be checked, for instance because the registry
+// cannot be reached, is reported with UpdateStatusUnknown rather than failing
+// the whole check.  Registries are queried concurrently.
+func (ir *Runtime) CheckImageUpdates(ctx context.Context, authfile string, dockerOptions *DockerRegistryOptions) ([]ImageUpdate, error) {
+	images, err := ir.GetImages()
+	if err != nil {
+		return nil, err
+	}
+	var checks []ImageUpdate
+	for _, img := range images {
+		localDigest := img.Digest()
+		if localDigest == "" {
+			continue
+		}
+		for _, name := range img.Names() {
+			if !hasRemoteSource(name) {
+				continue
+			}
+			checks = append(checks, ImageUpdate{
+				ID:          img.ID(),
+				Name:        name,
+				LocalDigest: localDigest,
+			})
+		}
+	}
+	results := checkImageUpdates(ctx, checks, func(ctx context.Context, name string) ([]digest.Digest, error) {
+		return getRemoteDigests(ctx, name, authfile, dockerOptions)
+	})
+	var updates []ImageUpdate
+	for _, result := range results {
+		if result.Status != UpdateStatusCurrent {
+			updates = append(updates, result)
+		}
+	}
+	return updates, nil
+}
+
+// checkImageUpdates sets the status of each check, querying at most
+// maxConcurrentUpdateChecks registries at once
+func checkImageUpdates(ctx context.Context, checks []ImageUpdate, remoteDigests remoteDigestsFunc) []ImageUpdate {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentUpdateChecks)
+	for n := range checks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(check *ImageUpdate) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			digests, err := remoteDigests(ctx, check.Name)
+			if err != nil {
+				check.Status = UpdateStatusUnknown
+				check.Error = err
+				return
+			}
+			check.RemoteDigest = digests[0]
+			check.Status = UpdateStatusOutdated
+			for _, d := range digests {
+				if d == check.LocalDigest {
+					check.Status = UpdateStatusCurrent
+				}
+			}
+		}(&checks[n])
+	}
+	wg.Wait()
+	return checks
+}
+
+// hasRemoteSource returns true if the image name is a tag in a registry,
+// rather than a digest or the name of a locally built image
+func hasRemoteSource(name string) bool {
+	named, err := reference.ParseNormalizedNamed(name)
+	if err != nil {
+		return false
+	}
+	if _, ok := named.(reference.Tagged); !ok {
+		return false
+	}
+	return reference.Domain(named) != "localhost"
+}
+
+// getRemoteDigests returns the digest of the manifest the named image resolves
+// to in its registry, followed, if that manifest is a manifest list, by the
+// digests of the manifests it lists, as a local image pulled through the list
+// has the digest of one of those
+func getRemoteDigests(ctx context.Context, name, authfile string, dockerOptions *DockerRegistryOptions) ([]digest.Digest, error) {
+	src, err := openRegistrySource(ctx, name, authfile, dockerOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	manifestBlob, manifestType, err := getRemoteManifest(ctx, src, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	manifestDigest, err := manifest.Digest(manifestBlob)
+	if err != nil {
+		return nil, err
+	}
+	digests := []digest.Digest{manifestDigest}
+	if manifestType == "" {
+		manifestType = manifest.GuessMIMEType(manifestBlob)
+	}
+	if manifestType == manifest.DockerV2ListMediaType || manifestType == ociv1.MediaTypeImageIndex {
+		var list platformList
+		if err := json.Unmarshal(manifestBlob, &list); err != nil {
+			return nil, err
+		}
+		for _, m := range list.Manifests {
+			digests = append(digests, m.Digest)
+		}
+	}
+	return digests, nil
+}
@@ -0,0 +1,73 @@
+package image
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckImageUpdates(t *testing.T) {
+	current := digest.FromString("current")
+	old := digest.FromString("old")
+	instance := digest.FromString("instance")
+
+	var checks []ImageUpdate
+	for _, name := range []string{"current", "outdated", "unreachable", "list"} {
+		checks = append(checks, ImageUpdate{Name: name, LocalDigest: current})
+	}
+	checks[3].LocalDigest = instance
+	for i := 0; i < 20; i++ {
+		checks = append(checks, ImageUpdate{Name: "current", LocalDigest: current})
+	}
+
+	var lock sync.Mutex
+	running, maxRunning := 0, 0
+	remoteDigests := func(ctx context.Context, name string) ([]digest.Digest, error) {
+		lock.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		lock.Unlock()
+		defer func() {
+			lock.Lock()
+			running--
+			lock.Unlock()
+		}()
+
+		switch name {
+		case "current":
+			return []digest.Digest{current}, nil
+		case "outdated":
+			return []digest.Digest{old}, nil
+		case "list":
+			return []digest.Digest{digest.FromString("list"), old, instance}, nil
+		}
+		return nil, errors.New("connection refused")
+	}
+
+	results := checkImageUpdates(context.Background(), checks, remoteDigests)
+	assert.Len(t, results, len(checks))
+	assert.Equal(t, UpdateStatusCurrent, results[0].Status)
+	assert.Equal(t, UpdateStatusOutdated, results[1].Status)
+	assert.Equal(t, old, results[1].RemoteDigest)
+	assert.Equal(t, UpdateStatusUnknown, results[2].Status)
+	assert.Error(t, results[2].Error)
+	assert.Equal(t, UpdateStatusCurrent, results[3].Status)
+	for _, result := range results[4:] {
+		assert.Equal(t, UpdateStatusCurrent, result.Status)
+	}
+	assert.True(t, maxRunning <= maxConcurrentUpdateChecks)
+}
+
+func TestHasRemoteSource(t *testing.T) {
+	assert.True(t, hasRemoteSource("docker.io/library/alpine:latest"))
+	assert.True(t, hasRemoteSource("registry.example.com:5000/app:1.0"))
+	assert.False(t, hasRemoteSource("localhost/built:latest"))
+	assert.False(t, hasRemoteSource("docker.io/library/alpine@"+digest.FromString("x").String()))
+	assert.False(t, hasRemoteSource("not a name"))
+}
@@ -62,6 +62,9 @@ func getCopyOptions(reportWriter io.Writer, signaturePolicyPath string, srcDocke
 	}
 	srcContext := srcDockerRegistry.GetSystemContext(signaturePolicyPath, authFile, forceCompress, additionalDockerArchiveTags)
 	destContext := destDockerRegistry.GetSystemContext(signaturePolicyPath, authFile, forceCompress, additionalDockerArchiveTags)
+	// TODO: the vendored containers/image copies layers one at a time and
+	// has no option to limit concurrent downloads.  Expose a bounded
+	// parallel download setting to pulls once copy.Options provides one.
 	return &cp.Options{
 		RemoveSignatures:      signing.RemoveSignatures,
 		SignBy:                signing.SignBy,
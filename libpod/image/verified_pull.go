@@ -0,0 +1,293 @@
+package image
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+
+	cp "github.com/containers/image/copy"
+	"github.com/containers/image/docker"
+	"github.com/containers/image/docker/reference"
+	"github.com/containers/image/manifest"
+	is "github.com/containers/image/storage"
+	"github.com/containers/image/types"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+const (
+	// cosignSignatureAnnotation is the annotation of a signature layer
+	// holding the base64-encoded signature of the layer's payload
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	// cosignSignatureType is the type of the payload of a cosign signature
+	cosignSignatureType = "cosign container image signature"
+	// maxSignaturePayloadSize limits the size of signature payloads read
+	// from a registry
+	maxSignaturePayloadSize = 1 << 20
+)
+
+// ErrSignatureNotVerified indicates that no signature of an image could be
+// verified with the given public key
+var ErrSignatureNotVerified = errors.New("no valid signature found for image")
+
+// cosignPayload is the payload of a cosign signature, in the "simple signing"
+// format
+type cosignPayload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest digest.Digest `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]interface{} `json:"optional"`
+}
+
+// PullImageVerified pulls the named image from its registry only if it has a
+// cosign signature which verifies with publicKey, a PEM-encoded ECDSA or RSA
+// public key.  The signature is looked up as cosign stores it, in the
+// image's repository under the tag "<algorithm>-<hex>.sig" derived from the
+// manifest digest, and must sign that manifest digest and repository.  The
+// image is pulled by the verified digest, so a tag moved after verification
+// is not followed.  If no signature verifies, the cause of the error is
+// ErrSignatureNotVerified and nothing is pulled.
+// The pulled image is returned along with the identity of its signer: the
+// "Subject" recorded in the signature, or else the SHA-256 fingerprint of the
+// public key.
+func (ir *Runtime) PullImageVerified(ctx context.Context, name, publicKey, signaturePolicyPath, authfile string, writer io.Writer, dockerOptions *DockerRegistryOptions) (*Image, string, error) {
+	key, err := parseVerificationKey(publicKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	src, err := openRegistrySource(ctx, name, authfile, dockerOptions)
+	if err != nil {
+		return nil, "", err
+	}
+	named := src.Reference().DockerReference()
+	manifestBlob, _, err := getRemoteManifest(ctx, src, name, nil)
+	src.Close()
+	if err != nil {
+		return nil, "", err
+	}
+	manifestDigest, err := manifest.Digest(manifestBlob)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sigRef, err := cosignSignatureReference(named, manifestDigest)
+	if err != nil {
+		return nil, "", err
+	}
+	sc := dockerOptions.GetSystemContext("", authfile, false, nil)
+	sigSrc, err := sigRef.NewImageSource(ctx, sc)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "error connecting to registry for %q", name)
+	}
+	identity, err := verifyCosignSignatures(ctx, sigSrc, key, reference.TrimNamed(named).String(), manifestDigest)
+	sigSrc.Close()
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "unable to verify %s", named.String())
+	}
+
+	// Pull exactly the manifest which was verified
+	digested, err := reference.WithDigest(reference.TrimNamed(named), manifestDigest)
+	if err != nil {
+		return nil, "", err
+	}
+	srcRef, err := docker.NewReference(digested)
+	if err != nil {
+		return nil, "", err
+	}
+	destName := reference.TagNameOnly(named).String()
+	if _, isDigested := named.(reference.Digested); isDigested {
+		destName = digested.String()
+	}
+	destRef, err := is.Transport.ParseStoreReference(ir.store, destName)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "error parsing dest reference name")
+	}
+	if signaturePolicyPath == "" {
+		signaturePolicyPath = ir.SignaturePolicyPath
+	}
+	policyContext, err := getPolicyContext(GetSystemContext(signaturePolicyPath, authfile, false))
+	if err != nil {
+		return nil, "", err
+	}
+	defer policyContext.Destroy()
+	copyOptions := getCopyOptions(writer, signaturePolicyPath, dockerOptions, nil, SigningOptions{}, authfile, "", false, nil)
+	if err := cp.Image(ctx, policyContext, destRef, ir.limitTransferRate(srcRef), copyOptions); err != nil {
+		return nil, "", errors.Wrapf(err, "unable to pull %s", digested.String())
+	}
+
+	img, err := ir.NewFromLocal(destName)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "error retrieving local image after pulling %s", name)
+	}
+	return img, identity, nil
+}
+
+// cosignSignatureReference returns the reference cosign stores the
+// signatures of a manifest under
+func cosignSignatureReference(named reference.Named, manifestDigest digest.Digest) (types.ImageReference, error) {
+	tag := fmt.Sprintf("%s-%s.sig", manifestDigest.Algorithm(), manifestDigest.Hex())
+	tagged, err := reference.WithTag(reference.TrimNamed(named), tag)
+	if err != nil {
+		return nil, err
+	}
+	return docker.NewReference(tagged)
+}
+
+// verifyCosignSignatures checks the signatures in a cosign signature image
+// until one verifies with the key and signs the manifest digest of the
+// repository, and returns the identity of its signer
+func verifyCosignSignatures(ctx context.Context, sigSrc types.ImageSource, key crypto.PublicKey, repository string, manifestDigest digest.Digest) (string, error) {
+	sigManifestBlob, _, err := getRemoteManifest(ctx, sigSrc, repository, nil)
+	if err != nil {
+		if errors.Cause(err) == ErrRemoteImageNotFound {
+			return "", errors.Wrapf(ErrSignatureNotVerified, "%s has no signatures", manifestDigest)
+		}
+		return "", err
+	}
+	var sigManifest ociv1.Manifest
+	if err := json.Unmarshal(sigManifestBlob, &sigManifest); err != nil {
+		return "", errors.Wrapf(err, "unable to parse signatures of %s", manifestDigest)
+	}
+
+	var failures []string
+	for _, layer := range sigManifest.Layers {
+		encoded, ok := layer.Annotations[cosignSignatureAnnotation]
+		if !ok {
+			continue
+		}
+		if layer.Size > maxSignaturePayloadSize {
+			failures = append(failures, fmt.Sprintf("signature %s is too large", layer.Digest))
+			continue
+		}
+		payload, err := readSignaturePayload(ctx, sigSrc, layer)
+		if err != nil {
+			return "", err
+		}
+		identity, err := verifyCosignSignature(payload, encoded, key, repository, manifestDigest)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("signature %s: %v", layer.Digest, err))
+			continue
+		}
+		return identity, nil
+	}
+	if len(failures) == 0 {
+		return "", errors.Wrapf(ErrSignatureNotVerified, "%s has no signatures", manifestDigest)
+	}
+	return "", errors.Wrapf(ErrSignatureNotVerified, "%v", failures)
+}
+
+// readSignaturePayload reads the payload of a signature layer, checking it
+// against the layer's digest
+func readSignaturePayload(ctx context.Context, sigSrc types.ImageSource, layer ociv1.Descriptor) ([]byte, error) {
+	if err := layer.Digest.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid signature digest %q", layer.Digest)
+	}
+	blob, _, err := sigSrc.GetBlob(ctx, types.BlobInfo{Digest: layer.Digest, Size: layer.Size})
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read signature %s", layer.Digest)
+	}
+	defer blob.Close()
+	payload, err := ioutil.ReadAll(io.LimitReader(blob, maxSignaturePayloadSize+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read signature %s", layer.Digest)
+	}
+	if layer.Digest.Algorithm().FromBytes(payload) != layer.Digest {
+		return nil, errors.Errorf("signature %s does not match its digest", layer.Digest)
+	}
+	return payload, nil
+}
+
+// verifyCosignSignature verifies the base64-encoded signature of a cosign
+// payload with the key, checks that the payload signs the manifest digest of
+// the repository, and returns the identity of the signer
+func verifyCosignSignature(payload []byte, encodedSignature string, key crypto.PublicKey, repository string, manifestDigest digest.Digest) (string, error) {
+	sig, err := base64.StdEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to decode signature")
+	}
+	hashed := sha256.Sum256(payload)
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		var ecdsaSig struct {
+			R, S *big.Int
+		}
+		if rest, err := asn1.Unmarshal(sig, &ecdsaSig); err != nil || len(rest) > 0 {
+			return "", errors.Errorf("invalid ECDSA signature")
+		}
+		if !ecdsa.Verify(k, hashed[:], ecdsaSig.R, ecdsaSig.S) {
+			return "", errors.Errorf("signature does not match the public key")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, hashed[:], sig); err != nil {
+			return "", errors.Errorf("signature does not match the public key")
+		}
+	default:
+		return "", errors.Errorf("unsupported public key type %T", key)
+	}
+
+	var p cosignPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return "", errors.Wrapf(err, "unable to parse signature payload")
+	}
+	if p.Critical.Type != cosignSignatureType {
+		return "", errors.Errorf("signature is of type %q, not %q", p.Critical.Type, cosignSignatureType)
+	}
+	if p.Critical.Image.DockerManifestDigest != manifestDigest {
+		return "", errors.Errorf("signature is for manifest %s, not %s", p.Critical.Image.DockerManifestDigest, manifestDigest)
+	}
+	if p.Critical.Identity.DockerReference != repository {
+		return "", errors.Errorf("signature is for repository %q, not %q", p.Critical.Identity.DockerReference, repository)
+	}
+
+	if subject, ok := p.Optional["Subject"].(string); ok && subject != "" {
+		return subject, nil
+	}
+	return keyFingerprint(key)
+}
+
+// parseVerificationKey parses a PEM-encoded public key
+func parseVerificationKey(publicKey string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return nil, errors.Errorf("public key is not PEM-encoded")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse public key")
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey:
+		return key, nil
+	default:
+		return nil, errors.Errorf("unsupported public key type %T, must be ECDSA or RSA", key)
+	}
+}
+
+// keyFingerprint returns the SHA-256 fingerprint of a public key
+func keyFingerprint(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,155 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/containers/image/types"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSignatureSource is an image source serving a cosign signature image
+type fakeSignatureSource struct {
+	types.ImageSource
+	manifest []byte
+	blobs    map[digest.Digest][]byte
+}
+
+func (s *fakeSignatureSource) GetManifest(ctx context.Context, instanceDigest *digest.Digest) ([]byte, string, error) {
+	return s.manifest, ociv1.MediaTypeImageManifest, nil
+}
+
+func (s *fakeSignatureSource) GetBlob(ctx context.Context, info types.BlobInfo) (io.ReadCloser, int64, error) {
+	blob, ok := s.blobs[info.Digest]
+	if !ok {
+		return nil, 0, errors.Errorf("no blob %s", info.Digest)
+	}
+	return ioutil.NopCloser(bytes.NewReader(blob)), int64(len(blob)), nil
+}
+
+// cosignSignature is a payload and its signature
+type cosignSignature struct {
+	payload   []byte
+	signature []byte
+}
+
+// signCosignPayload makes a cosign signature of the manifest digest of the
+// repository
+func signCosignPayload(t *testing.T, signer crypto.Signer, repository string, manifestDigest digest.Digest, subject string) cosignSignature {
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":%q},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":{"Subject":%q}}`, repository, manifestDigest, subject))
+	hashed := sha256.Sum256(payload)
+	sig, err := signer.Sign(rand.Reader, hashed[:], crypto.SHA256)
+	assert.NoError(t, err)
+	return cosignSignature{payload: payload, signature: sig}
+}
+
+// newSignatureSource serves the signatures as cosign stores them
+func newSignatureSource(t *testing.T, signatures ...cosignSignature) *fakeSignatureSource {
+	src := &fakeSignatureSource{blobs: make(map[digest.Digest][]byte)}
+	sigManifest := ociv1.Manifest{}
+	for _, sig := range signatures {
+		payloadDigest := digest.FromBytes(sig.payload)
+		src.blobs[payloadDigest] = sig.payload
+		sigManifest.Layers = append(sigManifest.Layers, ociv1.Descriptor{
+			MediaType:   "application/vnd.dev.cosign.simplesigning.v1+json",
+			Digest:      payloadDigest,
+			Size:        int64(len(sig.payload)),
+			Annotations: map[string]string{cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(sig.signature)},
+		})
+	}
+	manifestBlob, err := json.Marshal(sigManifest)
+	assert.NoError(t, err)
+	src.manifest = manifestBlob
+	return src
+}
+
+// publicKeyPEM PEM-encodes the public key of a signer
+func publicKeyPEM(t *testing.T, signer crypto.Signer) string {
+	der, err := x509.MarshalPKIXPublicKey(signer.Public())
+	assert.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestVerifyCosignSignatures(t *testing.T) {
+	ctx := context.Background()
+	repository := "docker.io/library/signed"
+	manifestDigest := digest.FromString("manifest")
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	key, err := parseVerificationKey(publicKeyPEM(t, ecKey))
+	assert.NoError(t, err)
+	fingerprint, err := keyFingerprint(key)
+	assert.NoError(t, err)
+
+	// A valid signature identifies its signer by subject, or by key
+	identity, err := verifyCosignSignatures(ctx, newSignatureSource(t, signCosignPayload(t, ecKey, repository, manifestDigest, "release@example.com")), key, repository, manifestDigest)
+	assert.NoError(t, err)
+	assert.Equal(t, "release@example.com", identity)
+	identity, err = verifyCosignSignatures(ctx, newSignatureSource(t, signCosignPayload(t, ecKey, repository, manifestDigest, "")), key, repository, manifestDigest)
+	assert.NoError(t, err)
+	assert.Equal(t, fingerprint, identity)
+
+	// One valid signature is enough
+	src := newSignatureSource(t, signCosignPayload(t, otherKey, repository, manifestDigest, "other"), signCosignPayload(t, ecKey, repository, manifestDigest, "release@example.com"))
+	identity, err = verifyCosignSignatures(ctx, src, key, repository, manifestDigest)
+	assert.NoError(t, err)
+	assert.Equal(t, "release@example.com", identity)
+
+	// RSA keys are supported
+	rsaPublic, err := parseVerificationKey(publicKeyPEM(t, rsaKey))
+	assert.NoError(t, err)
+	identity, err = verifyCosignSignatures(ctx, newSignatureSource(t, signCosignPayload(t, rsaKey, repository, manifestDigest, "rsa")), rsaPublic, repository, manifestDigest)
+	assert.NoError(t, err)
+	assert.Equal(t, "rsa", identity)
+
+	// Signatures by other keys, of other manifests or repositories, and
+	// missing signatures are rejected
+	for _, src := range []*fakeSignatureSource{
+		newSignatureSource(t, signCosignPayload(t, otherKey, repository, manifestDigest, "")),
+		newSignatureSource(t, signCosignPayload(t, ecKey, repository, digest.FromString("other"), "")),
+		newSignatureSource(t, signCosignPayload(t, ecKey, "docker.io/library/other", manifestDigest, "")),
+		newSignatureSource(t),
+	} {
+		_, err = verifyCosignSignatures(ctx, src, key, repository, manifestDigest)
+		assert.Error(t, err)
+		assert.Equal(t, ErrSignatureNotVerified, errors.Cause(err))
+	}
+
+	// A payload which does not match its digest is rejected
+	tampered := newSignatureSource(t, signCosignPayload(t, ecKey, repository, manifestDigest, ""))
+	for d := range tampered.blobs {
+		tampered.blobs[d] = []byte("{}")
+	}
+	_, err = verifyCosignSignatures(ctx, tampered, key, repository, manifestDigest)
+	assert.Error(t, err)
+
+	// An ECDSA signature must be ASN.1-encoded
+	_, err = verifyCosignSignature([]byte("{}"), base64.StdEncoding.EncodeToString([]byte("garbage")), key, repository, manifestDigest)
+	assert.Error(t, err)
+
+	_, err = parseVerificationKey("not a key")
+	assert.Error(t, err)
+}
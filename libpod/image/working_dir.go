@@ -0,0 +1,64 @@
+package image
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// WorkingDir returns the directory a container of the image starts in.  The
+// configuration normally records the absolute directory, but builders which
+// record relative WORKDIR instructions as given leave a relative directory,
+// which is resolved by accumulating the WORKDIR instructions recorded in the
+// image's history.  If the image sets no working directory, "/" is returned.
+func (i *Image) WorkingDir(ctx context.Context) (string, error) {
+	config, err := i.Config(ctx)
+	if err != nil {
+		return "", err
+	}
+	history, _, err := i.History(ctx)
+	if err != nil {
+		return "", err
+	}
+	return resolveWorkingDir(config.WorkingDir, history), nil
+}
+
+// resolveWorkingDir resolves the working directory of an image's configuration
+// using the WORKDIR instructions in the image's history
+func resolveWorkingDir(workingDir string, history []ociv1.History) string {
+	if workingDir == "" {
+		return "/"
+	}
+	if path.IsAbs(workingDir) {
+		return path.Clean(workingDir)
+	}
+	resolved, last := "/", ""
+	for _, entry := range history {
+		createdBy := strings.TrimSpace(entry.CreatedBy)
+		index := strings.Index(createdBy, "#(nop)")
+		if index < 0 {
+			continue
+		}
+		// A WORKDIR instruction, recorded as
+		// "/bin/sh -c #(nop) WORKDIR app"
+		fields := strings.Fields(createdBy[index+len("#(nop)"):])
+		if len(fields) != 2 || fields[0] != "WORKDIR" {
+			continue
+		}
+		last = fields[1]
+		if path.IsAbs(last) {
+			resolved = path.Clean(last)
+		} else {
+			resolved = path.Join(resolved, last)
+		}
+	}
+	// The last WORKDIR instruction is the one the configuration records;
+	// without it the history is incomplete, and the directory can only be
+	// taken as relative to "/"
+	if path.Clean(last) != path.Clean(workingDir) {
+		return path.Join("/", workingDir)
+	}
+	return resolved
+}
@@ -0,0 +1,56 @@
+package image
+
+import (
+	"context"
+	"testing"
+
+	"github.com/containers/storage"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveWorkingDir(t *testing.T) {
+	history := []ociv1.History{
+		{CreatedBy: "/bin/sh -c #(nop) ADD file:abc in / "},
+		{CreatedBy: "/bin/sh -c #(nop) WORKDIR /srv"},
+		{CreatedBy: "/bin/sh -c #(nop) WORKDIR app"},
+		{CreatedBy: "/bin/sh -c echo WORKDIR ignored"},
+		{CreatedBy: "/bin/sh -c #(nop) WORKDIR ./src/"},
+	}
+	assert.Equal(t, "/srv/app/src", resolveWorkingDir("./src/", history))
+	assert.Equal(t, "/srv/app/src", resolveWorkingDir("src", history))
+	assert.Equal(t, "/opt", resolveWorkingDir("/opt/", history))
+	assert.Equal(t, "/", resolveWorkingDir("", history))
+
+	reset := append(history, ociv1.History{CreatedBy: "/bin/sh -c #(nop) WORKDIR /data"}, ociv1.History{CreatedBy: "/bin/sh -c #(nop) WORKDIR ../logs"})
+	assert.Equal(t, "/logs", resolveWorkingDir("../logs", reset))
+
+	// Without a matching history, relative directories are taken as
+	// relative to "/"
+	assert.Equal(t, "/other", resolveWorkingDir("other", history))
+	assert.Equal(t, "/src", resolveWorkingDir("src", nil))
+}
+
+func TestImage_WorkingDir(t *testing.T) {
+	workdir, err := mkWorkDir()
+	assert.NoError(t, err)
+	so := storage.StoreOptions{
+		RunRoot:   workdir,
+		GraphRoot: workdir,
+	}
+	ir, err := NewImageRuntimeFromOptions(so)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	img := createConfiguredImage(t, ir, "docker.io/library/workdir:latest", `{"WorkingDir":"/app"}`)
+	dir, err := img.WorkingDir(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "/app", dir)
+
+	img = createConfiguredImage(t, ir, "docker.io/library/noworkdir:latest", `{"Cmd":["sh"]}`)
+	dir, err = img.WorkingDir(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "/", dir)
+
+	cleanup(workdir, ir)
+}
@@ -226,6 +226,30 @@ func (s *InMemoryState) SaveContainer(ctr *Container) error {
 	return nil
 }
 
+// RewriteContainerConfig replaces a container's configuration
+func (s *InMemoryState) RewriteContainerConfig(ctr *Container, newCfg *ContainerConfig) error {
+	// If the container is invalid, return error
+	if !ctr.valid {
+		return errors.Wrapf(ErrCtrRemoved, "container with ID %s is not valid", ctr.ID())
+	}
+
+	// If the container does not exist, return error
+	stateCtr, ok := s.containers[ctr.ID()]
+	if !ok {
+		ctr.valid = false
+		return errors.Wrapf(ErrNoSuchCtr, "container with ID %s not found in state", ctr.ID())
+	}
+
+	if newCfg.ID != ctr.ID() || newCfg.Name != ctr.Name() {
+		return errors.Wrapf(ErrInvalidArg, "cannot change the ID or name of container %s", ctr.ID())
+	}
+
+	stateCtr.config = newCfg
+	ctr.config = newCfg
+
+	return nil
+}
+
 // ContainerInUse checks if the given container is being used by other containers
 func (s *InMemoryState) ContainerInUse(ctr *Container) ([]string, error) {
 	if !ctr.valid {
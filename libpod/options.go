@@ -229,6 +229,24 @@ func WithTmpDir(dir string) RuntimeOption {
 	}
 }
 
+// WithTransferRateLimit sets the maximum combined rate, in bytes per second,
+// at which images are pulled, pushed and exported.  0 is unlimited.
+func WithTransferRateLimit(bytesPerSec int64) RuntimeOption {
+	return func(rt *Runtime) error {
+		if rt.valid {
+			return ErrRuntimeFinalized
+		}
+
+		if bytesPerSec < 0 {
+			return errors.Wrapf(ErrInvalidArg, "transfer rate limit must not be negative")
+		}
+
+		rt.config.TransferRateLimit = bytesPerSec
+
+		return nil
+	}
+}
+
 // WithMaxLogSize sets the maximum size of container logs.
 // Positive sizes are limits in bytes, -1 is unlimited.
 func WithMaxLogSize(limit int64) RuntimeOption {
@@ -973,3 +991,66 @@ func WithPodCgroups() PodCreateOption {
 		return nil
 	}
 }
+
+// WithPodDNS sets the DNS servers, search domains, and options used in the
+// resolv.conf of every container that joins the pod.
+// A container which sets its own servers, search domains, or options keeps
+// them.
+func WithPodDNS(servers, searches, options []string) PodCreateOption {
+	return func(pod *Pod) error {
+		if pod.valid {
+			return ErrPodFinalized
+		}
+
+		var dns []net.IP
+		for _, server := range servers {
+			result := net.ParseIP(server)
+			if result == nil {
+				return errors.Wrapf(ErrInvalidArg, "invalid DNS server IP address %s", server)
+			}
+			dns = append(dns, result)
+		}
+
+		pod.config.DNSServer = dns
+		pod.config.DNSSearch = searches
+		pod.config.DNSOption = options
+
+		return nil
+	}
+}
+
+// WithPodVolume declares a named volume that will be mounted at the given path
+// in every container that joins the pod.
+// A container which already mounts something at that path keeps its own mount.
+func WithPodVolume(name, mountPath string) PodCreateOption {
+	return func(pod *Pod) error {
+		if pod.valid {
+			return ErrPodFinalized
+		}
+
+		if !nameRegex.MatchString(name) {
+			return errors.Wrapf(ErrInvalidArg, "volume name must match regex [a-zA-Z0-9_-]+")
+		}
+
+		if !filepath.IsAbs(mountPath) {
+			return errors.Wrapf(ErrInvalidArg, "mount path of volume %s must be absolute", name)
+		}
+
+		for _, vol := range pod.config.Volumes {
+			if vol.Name == name {
+				return errors.Wrapf(ErrInvalidArg, "pod already has a volume named %s", name)
+			}
+			if filepath.Clean(vol.MountPath) == filepath.Clean(mountPath) {
+				return errors.Wrapf(ErrInvalidArg, "pod already has volume %s mounted at %s", vol.Name, mountPath)
+			}
+		}
+
+		pod.config.Volumes = append(pod.config.Volumes, &PodVolume{
+			Name:      name,
+			Source:    filepath.Join(pod.volumesDir(), name),
+			MountPath: mountPath,
+		})
+
+		return nil
+	}
+}
@@ -2,11 +2,13 @@ package libpod
 
 import (
 	"context"
+	"net"
 	"path/filepath"
 	"strings"
 
 	"github.com/containers/storage"
 	"github.com/docker/docker/pkg/stringid"
+	spec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -36,6 +38,27 @@ type PodConfig struct {
 	// If true, all containers joined to the pod will use the pod cgroup as
 	// their cgroup parent, and cannot set a different cgroup parent
 	UsePodCgroup bool
+
+	// Volumes are named volumes mounted into every container that joins
+	// the pod
+	Volumes []*PodVolume `json:"volumes,omitempty"`
+
+	// DNS servers used in the resolv.conf of containers in the pod
+	DNSServer []net.IP `json:"dnsServer,omitempty"`
+	// DNS search domains used in the resolv.conf of containers in the pod
+	DNSSearch []string `json:"dnsSearch,omitempty"`
+	// DNS options set in the resolv.conf of containers in the pod
+	DNSOption []string `json:"dnsOption,omitempty"`
+}
+
+// PodVolume is a named volume shared by all containers in a pod
+type PodVolume struct {
+	// Name is the name of the volume
+	Name string `json:"name"`
+	// Source is the directory on the host backing the volume
+	Source string `json:"source"`
+	// MountPath is the path the volume is mounted at in each container
+	MountPath string `json:"mountPath"`
 }
 
 // podState represents a pod's state
@@ -75,6 +98,31 @@ func (p *Pod) UsePodCgroup() bool {
 	return p.config.UsePodCgroup
 }
 
+// Volumes returns the named volumes shared by all containers in the pod
+func (p *Pod) Volumes() []PodVolume {
+	volumes := make([]PodVolume, 0, len(p.config.Volumes))
+	for _, vol := range p.config.Volumes {
+		volumes = append(volumes, *vol)
+	}
+
+	return volumes
+}
+
+// DNSServers returns the DNS servers used by containers in the pod
+func (p *Pod) DNSServers() []net.IP {
+	return p.config.DNSServer
+}
+
+// DNSSearch returns the DNS search domains used by containers in the pod
+func (p *Pod) DNSSearch() []string {
+	return p.config.DNSSearch
+}
+
+// DNSOption returns the DNS options used by containers in the pod
+func (p *Pod) DNSOption() []string {
+	return p.config.DNSOption
+}
+
 // CgroupPath returns the path to the pod's CGroup
 func (p *Pod) CgroupPath() (string, error) {
 	p.lock.Lock()
@@ -107,6 +155,52 @@ func newPod(lockDir string, runtime *Runtime) (*Pod, error) {
 	return pod, nil
 }
 
+// volumesDir returns the directory holding the pod's shared volumes
+func (p *Pod) volumesDir() string {
+	return filepath.Join(p.runtime.config.StaticDir, "pods", p.ID(), "volumes")
+}
+
+// addVolumesToCtr mounts the pod's shared volumes into the given container
+// A mount the container already has at the same path takes precedence over the
+// pod's volume
+func (p *Pod) addVolumesToCtr(ctr *Container) {
+	for _, vol := range p.config.Volumes {
+		collision := false
+		for _, mount := range ctr.config.Spec.Mounts {
+			if filepath.Clean(mount.Destination) == filepath.Clean(vol.MountPath) {
+				collision = true
+				break
+			}
+		}
+		if collision {
+			logrus.Warnf("container %s already mounts %s, not mounting pod %s volume %s there", ctr.ID(), vol.MountPath, p.ID(), vol.Name)
+			continue
+		}
+
+		ctr.config.Spec.Mounts = append(ctr.config.Spec.Mounts, spec.Mount{
+			Destination: vol.MountPath,
+			Type:        "bind",
+			Source:      vol.Source,
+			Options:     []string{"rbind", "rw"},
+		})
+	}
+}
+
+// addDNSToCtr gives the given container the pod's DNS configuration
+// DNS servers, search domains, or options the container sets itself take
+// precedence over the pod's
+func (p *Pod) addDNSToCtr(ctr *Container) {
+	if len(ctr.config.DNSServer) == 0 {
+		ctr.config.DNSServer = p.config.DNSServer
+	}
+	if len(ctr.config.DNSSearch) == 0 {
+		ctr.config.DNSSearch = p.config.DNSSearch
+	}
+	if len(ctr.config.DNSOption) == 0 {
+		ctr.config.DNSOption = p.config.DNSOption
+	}
+}
+
 // Update pod state from database
 func (p *Pod) updatePod() error {
 	if err := p.runtime.state.UpdatePod(p); err != nil {
@@ -200,9 +294,37 @@ func (p *Pod) Start(ctx context.Context) (map[string]error, error) {
 		startNode(ctx, node, false, ctrErrors, ctrsVisited)
 	}
 
+	publishPodEvent(p.ID(), PodEventStart, "", "")
+
 	return ctrErrors, nil
 }
 
+// StartOrder returns the IDs of the containers in the pod in the order Start
+// would start them, with each container after the containers it depends on.
+// Containers which do not depend on each other have no particular order.  An
+// error is returned if the dependencies of the containers form a cycle, or if
+// a container depends on a container outside the pod, as Start would fail.
+func (p *Pod) StartOrder() ([]string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if !p.valid {
+		return nil, ErrPodRemoved
+	}
+
+	allCtrs, err := p.runtime.state.PodContainers(p)
+	if err != nil {
+		return nil, err
+	}
+
+	graph, err := buildContainerGraph(allCtrs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error generating dependency graph for pod %s", p.ID())
+	}
+
+	return graph.startOrder(), nil
+}
+
 // Visit a node on a container graph and start the container, or set an error if
 // a dependency failed to start
 func startNode(ctx context.Context, node *containerNode, setError bool, ctrErrors map[string]error, ctrsVisited map[string]bool) {
@@ -353,6 +475,8 @@ func (p *Pod) Stop(cleanup bool) (map[string]error, error) {
 		ctr.lock.Unlock()
 	}
 
+	publishPodEvent(p.ID(), PodEventStop, "", "")
+
 	if len(ctrErrors) > 0 {
 		return ctrErrors, errors.Wrapf(ErrCtrExists, "error stopping some containers")
 	}
@@ -485,6 +609,66 @@ func (p *Pod) Status() (map[string]ContainerStatus, error) {
 	return status, nil
 }
 
+// Exit codes reported by ExitCodes for containers which have not exited
+const (
+	// PodExitCodeRunning is reported for containers which are running or
+	// paused
+	PodExitCodeRunning int32 = -1
+	// PodExitCodeNotStarted is reported for containers which have never
+	// been started
+	PodExitCodeNotStarted int32 = -2
+)
+
+// ExitCodes gets the exit codes of all containers in the pod
+// Returns a map of container name to exit code.  Containers which have not
+// exited are mapped to PodExitCodeRunning or PodExitCodeNotStarted, which
+// cannot be mistaken for exit codes.  Containers removed while the exit codes
+// are being gathered are omitted.
+func (p *Pod) ExitCodes() (map[string]int32, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if !p.valid {
+		return nil, ErrPodRemoved
+	}
+
+	allCtrs, err := p.runtime.state.PodContainers(p)
+	if err != nil {
+		return nil, err
+	}
+
+	exitCodes := make(map[string]int32, len(allCtrs))
+	for _, ctr := range allCtrs {
+		ctr.lock.Lock()
+		err := ctr.syncContainer()
+		state := *ctr.state
+		ctr.lock.Unlock()
+		if err != nil {
+			cause := errors.Cause(err)
+			if cause == ErrNoSuchCtr || cause == ErrCtrRemoved {
+				continue
+			}
+			return nil, err
+		}
+
+		exitCodes[ctr.Name()] = podExitCode(&state)
+	}
+
+	return exitCodes, nil
+}
+
+// podExitCode returns the exit code ExitCodes reports for a container in the
+// given state
+func podExitCode(state *containerState) int32 {
+	switch state.State {
+	case ContainerStateStopped:
+		return state.ExitCode
+	case ContainerStateRunning, ContainerStatePaused:
+		return PodExitCodeRunning
+	}
+	return PodExitCodeNotStarted
+}
+
 // TODO add pod batching
 // Lock pod to avoid lock contention
 // Store and lock all containers (no RemoveContainer in batch guarantees cache will not become stale)
@@ -0,0 +1,117 @@
+package libpod
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ContainerSpec describes a container that is going to be created in a pod,
+// for validating the containers of the pod before any of them are created
+type ContainerSpec struct {
+	// Name is the name of the container
+	Name string
+	// Dependencies are the containers the container depends on, such as
+	// the containers whose namespaces it joins
+	// Each is either the name of another container in the specs, or the
+	// name or ID of an existing container
+	Dependencies []string
+}
+
+// ValidatePodDependencies checks that the dependencies between the containers
+// described by specs can be satisfied: every dependency is either described
+// by another spec or an existing container, and the dependencies between the
+// described containers do not form a cycle.  If they do, the error names the
+// containers of the cycle in order, as in "a -> b -> a".
+func (r *Runtime) ValidatePodDependencies(specs []ContainerSpec) error {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if !r.valid {
+		return ErrRuntimeStopped
+	}
+
+	specsByName := make(map[string]*ContainerSpec, len(specs))
+	for i := range specs {
+		spec := &specs[i]
+		if spec.Name == "" {
+			return errors.Wrapf(ErrInvalidArg, "container specs must have a name")
+		}
+		if _, ok := specsByName[spec.Name]; ok {
+			return errors.Wrapf(ErrCtrExists, "more than one container spec is named %s", spec.Name)
+		}
+		specsByName[spec.Name] = spec
+	}
+
+	// Dependencies outside the specs must already exist, and cannot
+	// depend on the new containers, so they cannot be part of a cycle
+	for _, spec := range specs {
+		for _, dep := range spec.Dependencies {
+			if _, ok := specsByName[dep]; ok {
+				continue
+			}
+			if _, err := r.state.LookupContainer(dep); err != nil {
+				return errors.Wrapf(err, "container %s depends on container %s, which is neither in the pod nor an existing container", spec.Name, dep)
+			}
+		}
+	}
+
+	if cycle := findSpecCycle(specs, specsByName); cycle != nil {
+		return errors.Wrapf(ErrInvalidArg, "containers have a circular dependency: %s", strings.Join(cycle, " -> "))
+	}
+
+	return nil
+}
+
+// findSpecCycle returns the names of the containers forming a dependency
+// cycle among specs, starting and ending with the same container, or nil if
+// there is no cycle
+func findSpecCycle(specs []ContainerSpec, specsByName map[string]*ContainerSpec) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(specs))
+	// The chain of containers being visited, each depending on the last
+	var chain []string
+
+	var visit func(spec *ContainerSpec) []string
+	visit = func(spec *ContainerSpec) []string {
+		state[spec.Name] = visiting
+		chain = append(chain, spec.Name)
+		for _, dep := range spec.Dependencies {
+			depSpec, ok := specsByName[dep]
+			if !ok {
+				continue
+			}
+			switch state[dep] {
+			case visiting:
+				// The dependency is earlier in the chain, so the
+				// chain from it to here is a cycle
+				for i, name := range chain {
+					if name == dep {
+						cycle := append([]string{}, chain[i:]...)
+						return append(cycle, dep)
+					}
+				}
+			case unvisited:
+				if cycle := visit(depSpec); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		chain = chain[:len(chain)-1]
+		state[spec.Name] = visited
+		return nil
+	}
+
+	for i := range specs {
+		if state[specs[i].Name] == unvisited {
+			if cycle := visit(&specs[i]); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package libpod
+
+import (
+	"sync"
+	"time"
+)
+
+// PodEventType is the kind of a pod lifecycle event
+type PodEventType string
+
+const (
+	// PodEventCreate indicates that a pod was created
+	PodEventCreate PodEventType = "create"
+	// PodEventStart indicates that the containers of a pod were started
+	PodEventStart PodEventType = "start"
+	// PodEventStop indicates that the containers of a pod were stopped
+	PodEventStop PodEventType = "stop"
+	// PodEventRemove indicates that a pod was removed
+	PodEventRemove PodEventType = "remove"
+	// PodEventContainerState indicates that the state of a container in a
+	// pod was changed
+	PodEventContainerState PodEventType = "container-state"
+	// PodEventDropped is a marker indicating that events were dropped as
+	// the subscriber was not receiving them quickly enough
+	PodEventDropped PodEventType = "dropped"
+)
+
+// podEventBufferSize is the number of events buffered for each subscriber
+// before further events are dropped
+const podEventBufferSize = 100
+
+// PodEvent describes a change to a pod or one of its containers
+type PodEvent struct {
+	// PodID is the ID of the pod.  It is empty for PodEventDropped.
+	PodID string
+	// Type is the kind of the event
+	Type PodEventType
+	// ContainerID is the ID of the container affected, if any
+	ContainerID string
+	// ContainerState is the new state of the container for
+	// PodEventContainerState
+	ContainerState string
+	// Dropped is the number of events dropped for PodEventDropped
+	Dropped int
+	// Time is when the event happened
+	Time time.Time
+}
+
+// PodEventSubscription receives the pod events published in this process
+// until it is closed
+type PodEventSubscription struct {
+	events chan PodEvent
+	// dropped counts the events dropped since the last marker was sent
+	dropped int
+}
+
+// podEvents holds the subscriptions to pod events.  It is shared by all
+// runtimes in the process, as the varlink service creates one per call.
+var podEvents = struct {
+	lock          sync.Mutex
+	subscriptions map[*PodEventSubscription]bool
+}{
+	subscriptions: make(map[*PodEventSubscription]bool),
+}
+
+// SubscribePodEvents subscribes to the pod events published by all runtimes
+// in this process.  Events are buffered; if the subscriber falls behind,
+// events are dropped rather than blocking the runtime, and a PodEventDropped
+// event reporting how many were lost is delivered in their place.
+func (r *Runtime) SubscribePodEvents() *PodEventSubscription {
+	sub := &PodEventSubscription{
+		events: make(chan PodEvent, podEventBufferSize),
+	}
+	podEvents.lock.Lock()
+	defer podEvents.lock.Unlock()
+	podEvents.subscriptions[sub] = true
+	return sub
+}
+
+// Events returns the channel events are delivered on.  It is closed when the
+// subscription is closed.
+func (s *PodEventSubscription) Events() <-chan PodEvent {
+	return s.events
+}
+
+// Close ends the subscription
+func (s *PodEventSubscription) Close() {
+	podEvents.lock.Lock()
+	defer podEvents.lock.Unlock()
+	if podEvents.subscriptions[s] {
+		delete(podEvents.subscriptions, s)
+		close(s.events)
+	}
+}
+
+// publishPodEvent delivers an event to all subscribers without blocking
+func publishPodEvent(podID string, eventType PodEventType, ctrID, ctrState string) {
+	event := PodEvent{
+		PodID:          podID,
+		Type:           eventType,
+		ContainerID:    ctrID,
+		ContainerState: ctrState,
+		Time:           time.Now(),
+	}
+
+	podEvents.lock.Lock()
+	defer podEvents.lock.Unlock()
+	for sub := range podEvents.subscriptions {
+		if sub.dropped > 0 {
+			marker := PodEvent{
+				Type:    PodEventDropped,
+				Dropped: sub.dropped,
+				Time:    event.Time,
+			}
+			select {
+			case sub.events <- marker:
+				sub.dropped = 0
+			default:
+				sub.dropped++
+				continue
+			}
+		}
+		select {
+		case sub.events <- event:
+		default:
+			sub.dropped++
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package libpod
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPodEventsDelivered(t *testing.T) {
+	var r Runtime
+	sub := r.SubscribePodEvents()
+	defer sub.Close()
+
+	publishPodEvent("pod1", PodEventCreate, "", "")
+	publishPodEvent("pod1", PodEventContainerState, "ctr1", "running")
+
+	event := <-sub.Events()
+	assert.Equal(t, "pod1", event.PodID)
+	assert.Equal(t, PodEventCreate, event.Type)
+	event = <-sub.Events()
+	assert.Equal(t, PodEventContainerState, event.Type)
+	assert.Equal(t, "ctr1", event.ContainerID)
+	assert.Equal(t, "running", event.ContainerState)
+}
+
+func TestPodEventsDroppedForSlowSubscriber(t *testing.T) {
+	var r Runtime
+	sub := r.SubscribePodEvents()
+	defer sub.Close()
+
+	// Overflow the buffer without blocking the publisher
+	for i := 0; i < podEventBufferSize+5; i++ {
+		publishPodEvent("pod1", PodEventStart, "", "")
+	}
+	for i := 0; i < podEventBufferSize; i++ {
+		<-sub.Events()
+	}
+
+	// The next event is preceded by a marker counting those lost
+	publishPodEvent("pod1", PodEventStop, "", "")
+	event := <-sub.Events()
+	assert.Equal(t, PodEventDropped, event.Type)
+	assert.Equal(t, 5, event.Dropped)
+	event = <-sub.Events()
+	assert.Equal(t, PodEventStop, event.Type)
+}
+
+func TestPodEventsSubscriptionClosed(t *testing.T) {
+	var r Runtime
+	sub := r.SubscribePodEvents()
+	sub.Close()
+	sub.Close()
+
+	publishPodEvent("pod1", PodEventRemove, "", "")
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+}
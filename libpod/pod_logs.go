@@ -0,0 +1,247 @@
+package libpod
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// podLogReorderWindow is how long lines read while following the logs of a
+// pod are held back, so lines logged by other containers at about the same
+// time, but read later, can be ordered before them
+const podLogReorderWindow = time.Second
+
+// PodLogLine is a line logged by a container in a pod
+type PodLogLine struct {
+	// ContainerID is the ID of the container which logged the line
+	ContainerID string
+	// ContainerName is the name of the container which logged the line
+	ContainerName string
+	// Time is when the line was logged
+	Time time.Time
+	// Stream is the stream the line was written to, stdout or stderr
+	Stream string
+	// Line is the line, without its trailing newline
+	Line string
+}
+
+// containerLogFile is the log file of a container, read up to the last
+// complete line
+type containerLogFile struct {
+	id     string
+	name   string
+	file   *os.File
+	reader *bufio.Reader
+	// incomplete is the start of a line which was being written when the
+	// end of the file was reached
+	incomplete string
+	// partial is the line the container logged in parts so far
+	partial *PodLogLine
+	// last is the time of the last line read
+	last time.Time
+}
+
+// PodLogFollower reads the lines logged by the containers of a pod as they
+// are logged
+type PodLogFollower struct {
+	pod   *Pod
+	files map[string]*containerLogFile
+	// pending are the lines read but held back for reordering
+	pending []PodLogLine
+}
+
+// Logs returns the lines logged by the containers of the pod, merged in the
+// order they were logged.  If tail is greater than 0, only the last tail lines
+// are returned.
+func (p *Pod) Logs(tail int) ([]PodLogLine, error) {
+	lines, follower, err := p.FollowLogs(tail)
+	if err != nil {
+		return nil, err
+	}
+	follower.Close()
+	return lines, nil
+}
+
+// FollowLogs returns the lines logged by the containers of the pod as Logs
+// does, along with a follower returning the lines logged after them.  The
+// follower must be closed when it is no longer needed.
+func (p *Pod) FollowLogs(tail int) ([]PodLogLine, *PodLogFollower, error) {
+	follower := &PodLogFollower{
+		pod:   p,
+		files: make(map[string]*containerLogFile),
+	}
+	if err := follower.read(); err != nil {
+		follower.Close()
+		return nil, nil, err
+	}
+	lines := follower.release(time.Time{})
+	if tail > 0 && len(lines) > tail {
+		lines = lines[len(lines)-tail:]
+	}
+	return lines, follower, nil
+}
+
+// Next returns the lines logged since the last call, merged in the order they
+// were logged.  Lines are held back until they are podLogReorderWindow old, so
+// lines logged by different containers at about the same time are returned in
+// order.  Containers added to the pod are followed from the start of their
+// logs, and containers removed from it are dropped once the rest of their
+// logs has been read.  Once the pod has been removed, the remaining lines are
+// returned along with ErrNoSuchPod.
+func (f *PodLogFollower) Next() ([]PodLogLine, error) {
+	return f.next(time.Now())
+}
+
+// next returns the lines which are held back no longer at the given time
+func (f *PodLogFollower) next(now time.Time) ([]PodLogLine, error) {
+	if err := f.read(); err != nil {
+		cause := errors.Cause(err)
+		if cause == ErrNoSuchPod || cause == ErrPodRemoved {
+			return f.release(time.Time{}), ErrNoSuchPod
+		}
+		return nil, err
+	}
+	return f.release(now.Add(-podLogReorderWindow)), nil
+}
+
+// Close closes the log files of the containers
+func (f *PodLogFollower) Close() {
+	for id, file := range f.files {
+		file.file.Close()
+		delete(f.files, id)
+	}
+}
+
+// read reads the new lines of the logs of the pod's current containers.  If
+// the pod has been removed, the logs of the containers it had are read to
+// their end and closed before the error is returned.
+func (f *PodLogFollower) read() error {
+	ctrs, err := f.pod.AllContainers()
+	if err != nil {
+		cause := errors.Cause(err)
+		if cause != ErrNoSuchPod && cause != ErrPodRemoved {
+			return err
+		}
+	}
+	current := make(map[string]bool, len(ctrs))
+	for _, ctr := range ctrs {
+		current[ctr.ID()] = true
+		if _, ok := f.files[ctr.ID()]; ok {
+			continue
+		}
+		file, err := os.Open(ctr.LogPath())
+		if err != nil {
+			if os.IsNotExist(err) {
+				// The container has not logged anything yet
+				continue
+			}
+			return errors.Wrapf(err, "unable to open log file of container %s", ctr.ID())
+		}
+		f.files[ctr.ID()] = &containerLogFile{
+			id:     ctr.ID(),
+			name:   ctr.Name(),
+			file:   file,
+			reader: bufio.NewReader(file),
+		}
+	}
+
+	for id, file := range f.files {
+		lines, readErr := file.readLines()
+		f.pending = append(f.pending, lines...)
+		if !current[id] {
+			file.file.Close()
+			delete(f.files, id)
+		}
+		if readErr != nil {
+			return errors.Wrapf(readErr, "unable to read log file of container %s", id)
+		}
+	}
+	return err
+}
+
+// release returns the pending lines logged no later than the given time, or
+// all pending lines if the time is zero, in the order they were logged
+func (f *PodLogFollower) release(before time.Time) []PodLogLine {
+	sort.SliceStable(f.pending, func(i, j int) bool {
+		return f.pending[i].Time.Before(f.pending[j].Time)
+	})
+	count := len(f.pending)
+	if !before.IsZero() {
+		count = sort.Search(len(f.pending), func(i int) bool {
+			return f.pending[i].Time.After(before)
+		})
+	}
+	lines := make([]PodLogLine, count)
+	copy(lines, f.pending)
+	f.pending = f.pending[count:]
+	return lines
+}
+
+// readLines reads the complete lines added to the log file since it was last
+// read
+func (c *containerLogFile) readLines() ([]PodLogLine, error) {
+	var lines []PodLogLine
+	for {
+		text, err := c.reader.ReadString('\n')
+		if err != nil {
+			c.incomplete += text
+			if err == io.EOF {
+				return lines, nil
+			}
+			return lines, err
+		}
+		text = c.incomplete + strings.TrimSuffix(text, "\n")
+		c.incomplete = ""
+		if line := c.parseLine(text); line != nil {
+			lines = append(lines, *line)
+		}
+	}
+}
+
+// parseLine parses a line of the log file, recorded as
+// "<time> <stream> <F|P> <line>", and returns the line logged, or nil if it
+// is only part of a line
+func (c *containerLogFile) parseLine(text string) *PodLogLine {
+	line := &PodLogLine{
+		ContainerID:   c.id,
+		ContainerName: c.name,
+		Time:          c.last,
+		Line:          text,
+	}
+	partial := false
+	fields := strings.SplitN(text, " ", 3)
+	if len(fields) == 3 {
+		if logTime, err := time.Parse(time.RFC3339Nano, fields[0]); err == nil {
+			line.Time = logTime
+			line.Stream = fields[1]
+			line.Line = fields[2]
+			// Older versions of conmon do not record whether the
+			// line is complete
+			switch {
+			case line.Line == "F" || line.Line == "P":
+				partial = line.Line == "P"
+				line.Line = ""
+			case strings.HasPrefix(line.Line, "F ") || strings.HasPrefix(line.Line, "P "):
+				partial = line.Line[0] == 'P'
+				line.Line = line.Line[2:]
+			}
+		}
+	}
+	c.last = line.Time
+
+	if c.partial != nil {
+		c.partial.Line += line.Line
+		line = c.partial
+		c.partial = nil
+	}
+	if partial {
+		c.partial = line
+		return nil
+	}
+	return line
+}
@@ -0,0 +1,139 @@
+package libpod
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	selinux "github.com/opencontainers/selinux/go-selinux"
+	"github.com/opencontainers/selinux/go-selinux/label"
+	"github.com/pkg/errors"
+)
+
+// RelabelPod applies an SELinux file label, such as
+// "system_u:object_r:container_file_t:s0:c1,c2", to all containers in a pod.
+// Each container's rootfs is mounted with the new label from its next start,
+// and the volumes libpod created for it, along with the pod's volumes, are
+// relabeled now.  The level of the label is also applied to each container's
+// process label, so that the containers can still use their files.  Volumes
+// bind mounted from the host are not relabeled, as they may be shared with
+// the host.
+// No container in the pod may be running or paused; if one is, nothing is
+// relabeled.
+// An error and a map[string]error are returned, as with Pod.Start.  If the map
+// is not nil, relabeling failed for the containers it maps to errors, and the
+// error is set to ErrCtrExists.
+func (r *Runtime) RelabelPod(p *Pod, mountLabel string) (map[string]error, error) {
+	if err := validateFileLabel(mountLabel); err != nil {
+		return nil, err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if !p.valid {
+		return nil, ErrPodRemoved
+	}
+
+	allCtrs, err := r.state.PodContainers(p)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lock all the containers, so that none can start while the pod is
+	// being relabeled
+	for _, ctr := range allCtrs {
+		ctr.lock.Lock()
+		defer ctr.lock.Unlock()
+
+		if err := ctr.syncContainer(); err != nil {
+			return nil, err
+		}
+		if ctr.state.State == ContainerStateRunning || ctr.state.State == ContainerStatePaused {
+			return nil, errors.Wrapf(ErrCtrStateInvalid, "container %s in pod %s is %s, the pod must be stopped to relabel it", ctr.ID(), p.ID(), ctr.state.State.String())
+		}
+	}
+
+	if err := relabelDir(p.volumesDir(), mountLabel); err != nil {
+		return nil, errors.Wrapf(err, "error relabeling volumes of pod %s", p.ID())
+	}
+
+	ctrErrors := make(map[string]error)
+	for _, ctr := range allCtrs {
+		if err := ctr.relabel(mountLabel); err != nil {
+			ctrErrors[ctr.ID()] = err
+		}
+	}
+
+	if len(ctrErrors) > 0 {
+		return ctrErrors, errors.Wrapf(ErrCtrExists, "error relabeling some containers")
+	}
+
+	return nil, nil
+}
+
+// relabel applies a new SELinux file label to the container's volumes and
+// configuration
+// Must be called with the container locked, and the container not running
+func (c *Container) relabel(mountLabel string) error {
+	if err := relabelDir(filepath.Join(c.config.StaticDir, "volumes"), mountLabel); err != nil {
+		return errors.Wrapf(err, "error relabeling volumes of container %s", c.ID())
+	}
+
+	newCfg, err := relabeledConfig(c.config, mountLabel)
+	if err != nil {
+		return err
+	}
+	return c.runtime.state.RewriteContainerConfig(c, newCfg)
+}
+
+// relabeledConfig returns a copy of a container configuration with the given
+// mount label, and a process label at the same level
+func relabeledConfig(config *ContainerConfig, mountLabel string) (*ContainerConfig, error) {
+	newCfg := *config
+	newCfg.MountLabel = mountLabel
+	if config.ProcessLabel != "" {
+		if err := validateFileLabel(config.ProcessLabel); err != nil {
+			return nil, errors.Wrapf(err, "container %s has an invalid process label", config.ID)
+		}
+		processContext := selinux.NewContext(config.ProcessLabel)
+		processContext["level"] = selinux.NewContext(mountLabel)["level"]
+		newCfg.ProcessLabel = processContext.Get()
+	}
+
+	if config.Spec != nil {
+		newSpec := *config.Spec
+		if newSpec.Process != nil {
+			newProcess := *newSpec.Process
+			newProcess.SelinuxLabel = newCfg.ProcessLabel
+			newSpec.Process = &newProcess
+		}
+		if newSpec.Linux != nil {
+			newLinux := *newSpec.Linux
+			newLinux.MountLabel = mountLabel
+			newSpec.Linux = &newLinux
+		}
+		newCfg.Spec = &newSpec
+	}
+	return &newCfg, nil
+}
+
+// validateFileLabel checks that an SELinux label has user, role, type and
+// level parts
+func validateFileLabel(fileLabel string) error {
+	if len(strings.SplitN(fileLabel, ":", 4)) != 4 {
+		return errors.Wrapf(ErrInvalidArg, "SELinux label %q must be of the form user:role:type:level", fileLabel)
+	}
+	return nil
+}
+
+// relabelDir relabels a directory and its contents, if it exists
+func relabelDir(path, fileLabel string) error {
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return label.Relabel(path, fileLabel, false)
+}
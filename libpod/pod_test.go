@@ -0,0 +1,544 @@
+package libpod
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	spec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func findMount(mounts []spec.Mount, destination string) *spec.Mount {
+	for _, mount := range mounts {
+		if mount.Destination == destination {
+			return &mount
+		}
+	}
+	return nil
+}
+
+func TestPodVolumeSharedByAllContainers(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", tmpDirPrefix)
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	pod, err := getTestPod1(tmpDir)
+	assert.NoError(t, err)
+	pod.config.Volumes = []*PodVolume{
+		{
+			Name:      "scratch",
+			Source:    "/does/not/exist/scratch",
+			MountPath: "/scratch",
+		},
+	}
+
+	ctr1, err := getTestCtr1(tmpDir)
+	assert.NoError(t, err)
+	ctr2, err := getTestCtr2(tmpDir)
+	assert.NoError(t, err)
+
+	pod.addVolumesToCtr(ctr1)
+	pod.addVolumesToCtr(ctr2)
+
+	for _, ctr := range []*Container{ctr1, ctr2} {
+		mount := findMount(ctr.config.Spec.Mounts, "/scratch")
+		assert.NotNil(t, mount)
+		assert.Equal(t, "/does/not/exist/scratch", mount.Source)
+		assert.Equal(t, "bind", mount.Type)
+	}
+}
+
+func TestPodVolumeContainerMountWins(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", tmpDirPrefix)
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	pod, err := getTestPod1(tmpDir)
+	assert.NoError(t, err)
+	pod.config.Volumes = []*PodVolume{
+		{
+			Name:      "scratch",
+			Source:    "/does/not/exist/scratch",
+			MountPath: "/scratch",
+		},
+	}
+
+	ctr, err := getTestCtr1(tmpDir)
+	assert.NoError(t, err)
+	ctr.config.Spec.Mounts = append(ctr.config.Spec.Mounts, spec.Mount{
+		Destination: "/scratch/",
+		Type:        "bind",
+		Source:      "/does/not/exist/ctr",
+	})
+	numMounts := len(ctr.config.Spec.Mounts)
+
+	pod.addVolumesToCtr(ctr)
+
+	assert.Equal(t, numMounts, len(ctr.config.Spec.Mounts))
+	mount := findMount(ctr.config.Spec.Mounts, "/scratch/")
+	assert.NotNil(t, mount)
+	assert.Equal(t, "/does/not/exist/ctr", mount.Source)
+}
+
+func TestImageUsedByPods(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", tmpDirPrefix)
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	pod1, err := getTestPod1(tmpDir)
+	assert.NoError(t, err)
+	pod2, err := getTestPod2(tmpDir)
+	assert.NoError(t, err)
+
+	ctr1, err := getTestCtr1(tmpDir)
+	assert.NoError(t, err)
+	ctr1.config.Pod = pod1.ID()
+	ctr2, err := getTestCtr2(tmpDir)
+	assert.NoError(t, err)
+	ctr2.config.Pod = pod2.ID()
+	ctr2.config.RootfsImageID = ctr1.config.RootfsImageID
+	ctr3, err := getTestCtrN("3", tmpDir)
+	assert.NoError(t, err)
+	ctr3.config.Pod = pod1.ID()
+	ctr3.config.RootfsImageID = ctr1.config.RootfsImageID
+	ctr4, err := getTestCtrN("4", tmpDir)
+	assert.NoError(t, err)
+
+	ctrs := []*Container{ctr1, ctr2, ctr3, ctr4}
+	assert.Equal(t, []string{pod1.ID(), pod2.ID()}, imageUsedByPods(ctrs, ctr1.config.RootfsImageID))
+	assert.Empty(t, imageUsedByPods(ctrs, ctr4.config.RootfsImageID))
+}
+
+func TestPodDNSInvalidServer(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", tmpDirPrefix)
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	pod, err := getTestPod1(tmpDir)
+	assert.NoError(t, err)
+	pod.valid = false
+
+	err = WithPodDNS([]string{"10.0.0.1", "not-an-ip"}, nil, nil)(pod)
+	assert.Error(t, err)
+	assert.Equal(t, ErrInvalidArg, errors.Cause(err))
+}
+
+func TestPodDNSInResolvConf(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", tmpDirPrefix)
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	pod, err := getTestPod1(tmpDir)
+	assert.NoError(t, err)
+	pod.valid = false
+	assert.NoError(t, WithPodDNS([]string{"10.0.0.53", "fd00::53"}, []string{"svc.internal"}, []string{"ndots:2"})(pod))
+
+	ctr, err := getTestCtr1(tmpDir)
+	assert.NoError(t, err)
+	ctr.state.RunDir = tmpDir
+	// The test container sets its own DNS configuration, which would win
+	ctr.config.DNSServer = nil
+	ctr.config.DNSSearch = nil
+	ctr.config.DNSOption = nil
+	pod.addDNSToCtr(ctr)
+
+	_, err = ctr.generateResolvConf()
+	assert.NoError(t, err)
+	resolv, err := ioutil.ReadFile(filepath.Join(tmpDir, "resolv.conf"))
+	assert.NoError(t, err)
+	assert.Equal(t, "search svc.internal\nnameserver 10.0.0.53\nnameserver fd00::53\noptions ndots:2\n", string(resolv))
+}
+
+func TestCheckPodContainers(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", tmpDirPrefix)
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	pod, err := getTestPod1(tmpDir)
+	assert.NoError(t, err)
+
+	good, err := getTestCtr1(tmpDir)
+	assert.NoError(t, err)
+	good.config.Pod = pod.ID()
+	good.state.State = ContainerStateRunning
+	unknown, err := getTestCtr2(tmpDir)
+	assert.NoError(t, err)
+	unknown.config.Pod = pod.ID()
+	unknown.state.State = ContainerStateUnknown
+	elsewhere, err := getTestCtrN("3", tmpDir)
+	assert.NoError(t, err)
+	elsewhere.config.Pod = "otherpod"
+
+	ctrs := map[string]*Container{
+		good.ID():      good,
+		unknown.ID():   unknown,
+		elsewhere.ID(): elsewhere,
+	}
+	lookup := func(id string) (*Container, error) {
+		ctr, ok := ctrs[id]
+		if !ok {
+			return nil, ErrNoSuchCtr
+		}
+		return ctr, nil
+	}
+
+	problems, found := checkPodContainers(pod, []string{good.ID()}, lookup)
+	assert.Empty(t, problems)
+	assert.Equal(t, []*Container{good}, found)
+
+	problems, found = checkPodContainers(pod, []string{good.ID(), unknown.ID(), elsewhere.ID(), "missing"}, lookup)
+	assert.Len(t, problems, 3)
+	assert.Contains(t, problems[0], unknown.ID())
+	assert.Contains(t, problems[1], elsewhere.ID())
+	assert.Contains(t, problems[2], "missing")
+	assert.Equal(t, []*Container{good, unknown, elsewhere}, found)
+}
+
+func TestValidatePodCgroupParent(t *testing.T) {
+	for _, test := range []struct {
+		manager string
+		parent  string
+		valid   bool
+	}{
+		{CgroupfsCgroupsManager, "", true},
+		{CgroupfsCgroupsManager, "/libpod_parent", true},
+		{CgroupfsCgroupsManager, "/libpod_parent/pods", true},
+		{CgroupfsCgroupsManager, "machine.slice", false},
+		{CgroupfsCgroupsManager, "/system.slice/pods.slice", false},
+		{SystemdCgroupsManager, "", true},
+		{SystemdCgroupsManager, "machine.slice", true},
+		{SystemdCgroupsManager, "/machine.slice/pods.slice", true},
+		{SystemdCgroupsManager, "/libpod_parent", false},
+		{SystemdCgroupsManager, "pods.slice/child", false},
+		{"unknown", "", false},
+	} {
+		runtime := &Runtime{
+			config: &RuntimeConfig{CgroupManager: test.manager},
+			valid:  true,
+		}
+		err := runtime.ValidatePodCgroupParent(test.parent)
+		if test.valid {
+			assert.NoError(t, err, "%s with %s", test.parent, test.manager)
+		} else {
+			assert.Error(t, err, "%s with %s", test.parent, test.manager)
+			assert.Equal(t, ErrInvalidArg, errors.Cause(err))
+		}
+	}
+
+	runtime := &Runtime{config: &RuntimeConfig{CgroupManager: SystemdCgroupsManager}}
+	assert.Equal(t, ErrRuntimeStopped, runtime.ValidatePodCgroupParent("machine.slice"))
+}
+
+func TestRelabeledConfig(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", tmpDirPrefix)
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	ctr, err := getTestCtr1(tmpDir)
+	assert.NoError(t, err)
+	ctr.config.ProcessLabel = "system_u:system_r:container_t:s0:c1,c2"
+	ctr.config.MountLabel = "system_u:object_r:container_file_t:s0:c1,c2"
+	ctr.config.Spec.Process.SelinuxLabel = ctr.config.ProcessLabel
+	ctr.config.Spec.Linux.MountLabel = ctr.config.MountLabel
+
+	newLabel := "system_u:object_r:container_file_t:s0:c3,c4"
+	newCfg, err := relabeledConfig(ctr.config, newLabel)
+	assert.NoError(t, err)
+	assert.Equal(t, newLabel, newCfg.MountLabel)
+	assert.Equal(t, "system_u:system_r:container_t:s0:c3,c4", newCfg.ProcessLabel)
+	assert.Equal(t, newCfg.ProcessLabel, newCfg.Spec.Process.SelinuxLabel)
+	assert.Equal(t, newLabel, newCfg.Spec.Linux.MountLabel)
+
+	// The original configuration is unchanged
+	assert.Equal(t, "system_u:system_r:container_t:s0:c1,c2", ctr.config.Spec.Process.SelinuxLabel)
+	assert.Equal(t, "system_u:object_r:container_file_t:s0:c1,c2", ctr.config.Spec.Linux.MountLabel)
+
+	// Containers without a process label, such as privileged ones, keep
+	// none
+	ctr.config.ProcessLabel = ""
+	newCfg, err = relabeledConfig(ctr.config, newLabel)
+	assert.NoError(t, err)
+	assert.Empty(t, newCfg.ProcessLabel)
+	assert.Equal(t, newLabel, newCfg.MountLabel)
+}
+
+func TestRelabelPod(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", tmpDirPrefix)
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	state, err := NewInMemoryState()
+	assert.NoError(t, err)
+	runtime := &Runtime{
+		config: &RuntimeConfig{StaticDir: tmpDir},
+		state:  state,
+		valid:  true,
+	}
+
+	pod, err := getTestPod1(tmpDir)
+	assert.NoError(t, err)
+	pod.runtime = runtime
+	assert.NoError(t, state.AddPod(pod))
+
+	var ctrs []*Container
+	for _, n := range []string{"3", "4"} {
+		ctr, err := getTestCtrN(n, tmpDir)
+		assert.NoError(t, err)
+		ctr.config.Pod = pod.ID()
+		ctr.config.StaticDir = filepath.Join(tmpDir, "containers", ctr.ID())
+		ctr.config.ProcessLabel = "system_u:system_r:container_t:s0:c1,c2"
+		ctr.config.MountLabel = "system_u:object_r:container_file_t:s0:c1,c2"
+		ctr.state.State = ContainerStateConfigured
+		ctr.runtime = runtime
+		assert.NoError(t, state.AddContainerToPod(pod, ctr))
+		ctrs = append(ctrs, ctr)
+	}
+
+	_, err = runtime.RelabelPod(pod, "not-a-label")
+	assert.Error(t, err)
+
+	newLabel := "system_u:object_r:container_file_t:s0:c3,c4"
+	ctrErrors, err := runtime.RelabelPod(pod, newLabel)
+	assert.NoError(t, err)
+	assert.Empty(t, ctrErrors)
+	for _, ctr := range ctrs {
+		stored, err := state.Container(ctr.ID())
+		assert.NoError(t, err)
+		assert.Equal(t, newLabel, stored.MountLabel())
+		assert.Equal(t, "system_u:system_r:container_t:s0:c3,c4", stored.ProcessLabel())
+	}
+}
+
+func TestPodExitCode(t *testing.T) {
+	tests := []struct {
+		state    ContainerStatus
+		exitCode int32
+		expected int32
+	}{
+		{ContainerStateStopped, 0, 0},
+		{ContainerStateStopped, 137, 137},
+		{ContainerStateRunning, 0, PodExitCodeRunning},
+		{ContainerStatePaused, 0, PodExitCodeRunning},
+		{ContainerStateCreated, 0, PodExitCodeNotStarted},
+		{ContainerStateConfigured, 0, PodExitCodeNotStarted},
+		{ContainerStateUnknown, 0, PodExitCodeNotStarted},
+	}
+	for _, test := range tests {
+		state := &containerState{State: test.state, ExitCode: test.exitCode}
+		assert.Equal(t, test.expected, podExitCode(state), test.state.String())
+	}
+}
+
+func TestValidatePodDependencies(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", tmpDirPrefix)
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	state, err := NewInMemoryState()
+	assert.NoError(t, err)
+	existing, err := getTestCtrN("3", tmpDir)
+	assert.NoError(t, err)
+	assert.NoError(t, state.AddContainer(existing))
+	runtime := &Runtime{
+		config: &RuntimeConfig{StaticDir: tmpDir},
+		state:  state,
+		valid:  true,
+	}
+
+	// Dependencies on other specs and existing containers are valid
+	assert.NoError(t, runtime.ValidatePodDependencies([]ContainerSpec{
+		{Name: "infra", Dependencies: []string{existing.Name()}},
+		{Name: "a", Dependencies: []string{"infra"}},
+		{Name: "b", Dependencies: []string{"infra", "a", existing.ID()}},
+	}))
+	assert.NoError(t, runtime.ValidatePodDependencies(nil))
+
+	// A cycle is reported with the containers forming it
+	err = runtime.ValidatePodDependencies([]ContainerSpec{
+		{Name: "a", Dependencies: []string{"b"}},
+		{Name: "b", Dependencies: []string{"a"}},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, ErrInvalidArg, errors.Cause(err))
+	assert.Contains(t, err.Error(), "circular dependency: a -> b -> a")
+
+	err = runtime.ValidatePodDependencies([]ContainerSpec{
+		{Name: "infra"},
+		{Name: "a", Dependencies: []string{"infra", "c"}},
+		{Name: "b", Dependencies: []string{"a"}},
+		{Name: "c", Dependencies: []string{"b"}},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "circular dependency: a -> c -> b -> a")
+
+	err = runtime.ValidatePodDependencies([]ContainerSpec{{Name: "a", Dependencies: []string{"a"}}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "circular dependency: a -> a")
+
+	// Unknown dependencies, duplicate and missing names are rejected
+	err = runtime.ValidatePodDependencies([]ContainerSpec{{Name: "a", Dependencies: []string{"missing"}}})
+	assert.Error(t, err)
+	assert.Equal(t, ErrNoSuchCtr, errors.Cause(err))
+	err = runtime.ValidatePodDependencies([]ContainerSpec{{Name: "a"}, {Name: "a"}})
+	assert.Error(t, err)
+	assert.Equal(t, ErrCtrExists, errors.Cause(err))
+	assert.Error(t, runtime.ValidatePodDependencies([]ContainerSpec{{}}))
+
+	runtime.valid = false
+	assert.Equal(t, ErrRuntimeStopped, runtime.ValidatePodDependencies(nil))
+}
+
+func TestListOrphanedPodVolumes(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", tmpDirPrefix)
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	state, err := NewInMemoryState()
+	assert.NoError(t, err)
+	runtime := &Runtime{
+		config: &RuntimeConfig{StaticDir: tmpDir},
+		state:  state,
+		valid:  true,
+	}
+
+	// No pod has ever had volumes
+	orphans, err := runtime.ListOrphanedPodVolumes()
+	assert.NoError(t, err)
+	assert.Empty(t, orphans)
+
+	pod, err := getTestPod1(tmpDir)
+	assert.NoError(t, err)
+	pod.runtime = runtime
+	assert.NoError(t, state.AddPod(pod))
+
+	writeVolume := func(podID, name string, size int) string {
+		path := filepath.Join(tmpDir, "pods", podID, "volumes", name)
+		assert.NoError(t, os.MkdirAll(filepath.Join(path, "sub"), 0755))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(path, "sub", "data"), make([]byte, size), 0644))
+		return path
+	}
+	writeVolume(pod.ID(), "current", 10)
+	small := writeVolume("removed", "small", 10)
+	large := writeVolume("removed", "large", 1000)
+	used := writeVolume("removed", "used", 100)
+	other := writeVolume("removed-too", "other", 100)
+
+	// A standalone container still mounts a directory in one volume
+	ctr, err := getTestCtrN("3", tmpDir)
+	assert.NoError(t, err)
+	ctr.config.Spec.Mounts = append(ctr.config.Spec.Mounts, spec.Mount{
+		Destination: "/data",
+		Type:        "bind",
+		Source:      filepath.Join(used, "sub"),
+	})
+	assert.NoError(t, state.AddContainer(ctr))
+
+	orphans, err = runtime.ListOrphanedPodVolumes()
+	assert.NoError(t, err)
+	assert.Equal(t, []OrphanedPodVolume{
+		{Pod: "removed", Name: "large", Path: large, Size: 1000},
+		{Pod: "removed-too", Name: "other", Path: other, Size: 100},
+		{Pod: "removed", Name: "small", Path: small, Size: 10},
+	}, orphans)
+
+	runtime.valid = false
+	_, err = runtime.ListOrphanedPodVolumes()
+	assert.Equal(t, ErrRuntimeStopped, err)
+}
+
+func TestPodLogs(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", tmpDirPrefix)
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	state, err := NewInMemoryState()
+	assert.NoError(t, err)
+	runtime := &Runtime{
+		config: &RuntimeConfig{StaticDir: tmpDir},
+		state:  state,
+		valid:  true,
+	}
+
+	pod, err := getTestPod1(tmpDir)
+	assert.NoError(t, err)
+	pod.runtime = runtime
+	assert.NoError(t, state.AddPod(pod))
+
+	appendLog := func(ctr *Container, text string) {
+		file, err := os.OpenFile(ctr.LogPath(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		assert.NoError(t, err)
+		_, err = file.WriteString(text)
+		assert.NoError(t, err)
+		assert.NoError(t, file.Close())
+	}
+	addCtr := func(n string) *Container {
+		ctr, err := getTestCtrN(n, tmpDir)
+		assert.NoError(t, err)
+		ctr.config.Pod = pod.ID()
+		ctr.config.LogPath = filepath.Join(tmpDir, "ctr"+n+".log")
+		ctr.runtime = runtime
+		assert.NoError(t, state.AddContainerToPod(pod, ctr))
+		return ctr
+	}
+	texts := func(lines []PodLogLine) []string {
+		var texts []string
+		for _, line := range lines {
+			texts = append(texts, line.ContainerName+": "+line.Line)
+		}
+		return texts
+	}
+
+	ctr3 := addCtr("3")
+	ctr4 := addCtr("4")
+	appendLog(ctr3, "2018-06-01T10:00:00.000000000+00:00 stdout F one\n"+
+		"2018-06-01T10:00:02.000000000+00:00 stderr P thr\n"+
+		"2018-06-01T10:00:02.500000000+00:00 stderr F ee\n")
+	appendLog(ctr4, "2018-06-01T10:00:01.000000000+00:00 stdout F two\n"+
+		"2018-06-01T10:00:03.000000000+00:00 stdout four\n"+
+		"2018-06-01T10:00:04.000000000+00:00 stdout F incompl")
+
+	lines, err := pod.Logs(0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test3: one", "test4: two", "test3: three", "test4: four"}, texts(lines))
+	assert.Equal(t, "stderr", lines[2].Stream)
+	assert.Equal(t, ctr3.ID(), lines[2].ContainerID)
+
+	lines, err = pod.Logs(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test4: four"}, texts(lines))
+
+	lines, follower, err := pod.FollowLogs(2)
+	assert.NoError(t, err)
+	defer follower.Close()
+	assert.Equal(t, []string{"test3: three", "test4: four"}, texts(lines))
+
+	// Lines are held back until they can no longer be preceded by lines
+	// read later
+	appendLog(ctr4, "ete\n")
+	ctr5 := addCtr("5")
+	appendLog(ctr5, "2018-06-01T10:00:05.000000000+00:00 stdout F five\n")
+	now, err := time.Parse(time.RFC3339Nano, "2018-06-01T10:00:05.500000000+00:00")
+	assert.NoError(t, err)
+	lines, err = follower.next(now)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test4: incomplete"}, texts(lines))
+
+	// The rest of the log of a removed container is still returned
+	appendLog(ctr3, "2018-06-01T10:00:04.500000000+00:00 stdout F late\n")
+	assert.NoError(t, state.RemoveContainerFromPod(pod, ctr3))
+	lines, err = follower.next(now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test3: late", "test5: five"}, texts(lines))
+	appendLog(ctr3, "2018-06-01T10:00:06.000000000+00:00 stdout F gone\n")
+
+	appendLog(ctr5, "2018-06-01T10:00:07.000000000+00:00 stdout F last\n")
+	assert.NoError(t, state.RemovePodContainers(pod))
+	assert.NoError(t, state.RemovePod(pod))
+	lines, err = follower.next(now)
+	assert.Equal(t, ErrNoSuchPod, err)
+	assert.Equal(t, []string{"test5: last"}, texts(lines))
+}
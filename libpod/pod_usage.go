@@ -0,0 +1,112 @@
+package libpod
+
+import (
+	"github.com/containerd/cgroups"
+	"github.com/pkg/errors"
+)
+
+// PodUsage is the current resource usage of a pod
+type PodUsage struct {
+	// CPUNano is the CPU time used, in nanoseconds
+	CPUNano uint64
+	// MemUsage is the memory used, in bytes
+	MemUsage uint64
+	// PIDs is the number of processes
+	PIDs uint64
+	// FromPodCgroup is true if the usage was read from the pod's cgroup,
+	// and false if it is the sum of the usage of its running containers
+	FromPodCgroup bool
+	// Containers is the usage of each container which was running when
+	// the usage was collected
+	Containers map[string]*ContainerUsage
+}
+
+// ContainerUsage is the current resource usage of a container
+type ContainerUsage struct {
+	CPUNano  uint64
+	MemUsage uint64
+	PIDs     uint64
+}
+
+// Usage returns the current resource usage of the pod.  The usage of each
+// running container is read from its cgroup; containers which are not running,
+// or which exit while their usage is collected, are left out.  The pod's
+// total is read from its cgroup if it has one, and is otherwise the sum of the
+// usage of its containers.
+func (p *Pod) Usage() (*PodUsage, error) {
+	ctrs, err := p.AllContainers()
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &PodUsage{
+		Containers: make(map[string]*ContainerUsage),
+	}
+	for _, ctr := range ctrs {
+		ctrUsage, err := ctr.usage()
+		if err != nil || ctrUsage == nil {
+			continue
+		}
+		usage.Containers[ctr.ID()] = ctrUsage
+		usage.CPUNano += ctrUsage.CPUNano
+		usage.MemUsage += ctrUsage.MemUsage
+		usage.PIDs += ctrUsage.PIDs
+	}
+
+	cgroupPath, err := p.CgroupPath()
+	if err != nil {
+		return nil, err
+	}
+	if cgroupPath != "" && p.runtime.config.CgroupManager == CgroupfsCgroupsManager {
+		if podUsage, err := cgroupUsage(cgroupPath); err == nil {
+			usage.CPUNano = podUsage.CPUNano
+			usage.MemUsage = podUsage.MemUsage
+			usage.PIDs = podUsage.PIDs
+			usage.FromPodCgroup = true
+		}
+	}
+
+	return usage, nil
+}
+
+// usage returns the resource usage of the container, or nil if it is not
+// running
+func (c *Container) usage() (*ContainerUsage, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.syncContainer(); err != nil {
+		return nil, err
+	}
+	if c.state.State != ContainerStateRunning && c.state.State != ContainerStatePaused {
+		return nil, nil
+	}
+	cgroupPath, err := c.CGroupPath()
+	if err != nil {
+		return nil, err
+	}
+	return cgroupUsage(cgroupPath)
+}
+
+// cgroupUsage reads the resource usage of a cgroup
+func cgroupUsage(cgroupPath string) (*ContainerUsage, error) {
+	cgroup, err := cgroups.Load(cgroups.V1, cgroups.StaticPath(cgroupPath))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to load cgroup at %s", cgroupPath)
+	}
+	stats, err := cgroup.Stat(cgroups.IgnoreNotExist)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to obtain cgroup stats")
+	}
+	usage := new(ContainerUsage)
+	if stats.CPU != nil && stats.CPU.Usage != nil {
+		usage.CPUNano = stats.CPU.Usage.Total
+	}
+	if stats.Memory != nil && stats.Memory.Usage != nil {
+		usage.MemUsage = stats.Memory.Usage.Usage
+	}
+	if stats.Pids != nil {
+		usage.PIDs = stats.Pids.Current
+	}
+	return usage, nil
+}
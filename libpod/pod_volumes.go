@@ -0,0 +1,139 @@
+package libpod
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// OrphanedPodVolume is a volume created for a pod which no longer exists, and
+// which no container mounts
+type OrphanedPodVolume struct {
+	// Pod is the ID of the pod the volume was created for
+	Pod string
+	// Name is the name of the volume
+	Name string
+	// Path is the directory on the host backing the volume
+	Path string
+	// Size is the total size of the files in the volume, in bytes
+	Size int64
+}
+
+// podsDir returns the directory holding the directories of all pods
+func (r *Runtime) podsDir() string {
+	return filepath.Join(r.config.StaticDir, "pods")
+}
+
+// ListOrphanedPodVolumes returns the volumes which were created for pods that
+// have since been removed, but were left behind, and which no pod or container
+// still mounts.  The volumes are ordered by size, largest first, so the ones
+// reclaiming the most space can be removed first.
+func (r *Runtime) ListOrphanedPodVolumes() ([]OrphanedPodVolume, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if !r.valid {
+		return nil, ErrRuntimeStopped
+	}
+
+	pods, err := r.state.AllPods()
+	if err != nil {
+		return nil, err
+	}
+	podIDs := make(map[string]bool, len(pods))
+	for _, pod := range pods {
+		podIDs[pod.ID()] = true
+	}
+
+	ctrs, err := r.state.AllContainers()
+	if err != nil {
+		return nil, err
+	}
+	var mountSources []string
+	for _, ctr := range ctrs {
+		if ctr.config.Spec == nil {
+			continue
+		}
+		for _, mount := range ctr.config.Spec.Mounts {
+			mountSources = append(mountSources, filepath.Clean(mount.Source))
+		}
+	}
+
+	podDirs, err := ioutil.ReadDir(r.podsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "error reading pod directories")
+	}
+
+	var orphans []OrphanedPodVolume
+	for _, podDir := range podDirs {
+		if !podDir.IsDir() || podIDs[podDir.Name()] {
+			continue
+		}
+		volumesDir := filepath.Join(r.podsDir(), podDir.Name(), "volumes")
+		volumes, err := ioutil.ReadDir(volumesDir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "error reading volumes of pod %s", podDir.Name())
+		}
+		for _, volume := range volumes {
+			if !volume.IsDir() {
+				continue
+			}
+			path := filepath.Join(volumesDir, volume.Name())
+			if isMounted(path, mountSources) {
+				continue
+			}
+			size, err := dirSize(path)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error computing size of volume %s of pod %s", volume.Name(), podDir.Name())
+			}
+			orphans = append(orphans, OrphanedPodVolume{
+				Pod:  podDir.Name(),
+				Name: volume.Name(),
+				Path: path,
+				Size: size,
+			})
+		}
+	}
+
+	sort.SliceStable(orphans, func(i, j int) bool {
+		return orphans[i].Size > orphans[j].Size
+	})
+
+	return orphans, nil
+}
+
+// isMounted returns true if the path, or anything in it, is one of the mount
+// sources
+func isMounted(path string, mountSources []string) bool {
+	for _, source := range mountSources {
+		if source == path || strings.HasPrefix(source, path+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// dirSize returns the total size of the regular files in a directory tree
+func dirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
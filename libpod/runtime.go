@@ -121,6 +121,10 @@ type RuntimeConfig struct {
 	TmpDir string `toml:"tmp_dir"`
 	// MaxLogSize is the maximum size of container logfiles
 	MaxLogSize int64 `toml:"max_log_size,omitempty"`
+	// TransferRateLimit is the maximum combined rate, in bytes per second,
+	// at which images are pulled, pushed and exported
+	// 0 is unlimited
+	TransferRateLimit int64 `toml:"transfer_rate_limit,omitempty"`
 	// NoPivotRoot sets whether to set no-pivot-root in the OCI runtime
 	NoPivotRoot bool `toml:"no_pivot_root"`
 	// CNIConfigDir sets the directory where CNI configuration files are
@@ -311,7 +315,7 @@ func makeRuntime(runtime *Runtime) error {
 
 	// Set up image runtime and store in runtime
 	ir := image.NewImageRuntimeFromStore(runtime.store)
-	if err != nil {
+	if err := ir.SetTransferRateLimit(runtime.config.TransferRateLimit); err != nil {
 		return err
 	}
 
@@ -59,6 +59,12 @@ func (r *Runtime) NewContainer(ctx context.Context, rSpec *spec.Spec, options ..
 		if err != nil {
 			return nil, errors.Wrapf(err, "cannot add container %s to pod %s", ctr.ID(), ctr.config.Pod)
 		}
+
+		// Mount the pod's shared volumes into the container
+		pod.addVolumesToCtr(ctr)
+
+		// Give the container the pod's DNS configuration
+		pod.addDNSToCtr(ctr)
 	}
 
 	if ctr.config.Name == "" {
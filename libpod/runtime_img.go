@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/containers/image/directory"
 	"github.com/containers/image/docker"
@@ -102,6 +103,13 @@ func (r *Runtime) RemoveImage(ctx context.Context, image *image.Image, force boo
 				}
 			}
 		} else {
+			activePods, err := imageUsedByActivePods(ctrs, image.ID())
+			if err != nil {
+				return "", err
+			}
+			if len(activePods) > 0 {
+				return "", errors.Wrapf(ErrImageUsedByPods, "could not remove image %s as it is being used by containers of pods %s", image.ID(), strings.Join(activePods, ", "))
+			}
 			return "", fmt.Errorf("could not remove image %s as it is being used by %d containers", image.ID(), len(imageCtrs))
 		}
 	}
@@ -136,6 +144,68 @@ func (r *Runtime) RemoveImage(ctx context.Context, image *image.Image, force boo
 	return image.ID(), err
 }
 
+// ImageUsedByPods returns the IDs of the pods which have containers using the
+// given image
+func (r *Runtime) ImageUsedByPods(image *image.Image) ([]string, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if !r.valid {
+		return nil, ErrRuntimeStopped
+	}
+
+	ctrs, err := r.state.AllContainers()
+	if err != nil {
+		return nil, err
+	}
+	return imageUsedByPods(ctrs, image.ID()), nil
+}
+
+// imageUsedByPods returns the IDs of the pods which have containers using the
+// given image, in the order they are first seen
+func imageUsedByPods(ctrs []*Container, imageID string) []string {
+	var pods []string
+	seen := make(map[string]bool)
+	for _, ctr := range ctrs {
+		if ctr.config.RootfsImageID != imageID || ctr.PodID() == "" || seen[ctr.PodID()] {
+			continue
+		}
+		seen[ctr.PodID()] = true
+		pods = append(pods, ctr.PodID())
+	}
+	return pods
+}
+
+// imageUsedByActivePods returns the IDs of the pods which have containers
+// using the given image and at least one running or paused container.  ctrs
+// must hold all containers, so that pods can be checked in full.
+func imageUsedByActivePods(ctrs []*Container, imageID string) ([]string, error) {
+	pods := imageUsedByPods(ctrs, imageID)
+	if len(pods) == 0 {
+		return nil, nil
+	}
+	active := make(map[string]bool)
+	for _, ctr := range ctrs {
+		if ctr.PodID() == "" || active[ctr.PodID()] {
+			continue
+		}
+		state, err := ctr.State()
+		if err != nil {
+			return nil, errors.Wrapf(err, "error retrieving state of container %s", ctr.ID())
+		}
+		if state == ContainerStateRunning || state == ContainerStatePaused {
+			active[ctr.PodID()] = true
+		}
+	}
+	var activePods []string
+	for _, pod := range pods {
+		if active[pod] {
+			activePods = append(activePods, pod)
+		}
+	}
+	return activePods, nil
+}
+
 // Remove containers that are in storage rather than Podman.
 func (r *Runtime) rmStorageContainers(force bool, image *image.Image) error {
 	ctrIDs, err := storageContainers(image.ID(), r.store)
@@ -2,6 +2,8 @@ package libpod
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"path"
 	"path/filepath"
 	"strings"
@@ -53,20 +55,19 @@ func (r *Runtime) NewPod(options ...PodCreateOption) (*Pod, error) {
 	pod.valid = true
 
 	// Check CGroup parent sanity, and set it if it was not set
+	if err := r.validatePodCgroupParent(pod.config.CgroupParent); err != nil {
+		return nil, err
+	}
 	switch r.config.CgroupManager {
 	case CgroupfsCgroupsManager:
 		if pod.config.CgroupParent == "" {
 			pod.config.CgroupParent = CgroupfsDefaultCgroupParent
-		} else if strings.HasSuffix(path.Base(pod.config.CgroupParent), ".slice") {
-			return nil, errors.Wrapf(ErrInvalidArg, "systemd slice received as cgroup parent when using cgroupfs")
 		}
 		// Creating CGroup path is currently a NOOP until proper systemd
 		// cgroup management is merged
 	case SystemdCgroupsManager:
 		if pod.config.CgroupParent == "" {
 			pod.config.CgroupParent = SystemdDefaultCgroupParent
-		} else if len(pod.config.CgroupParent) < 6 || !strings.HasSuffix(path.Base(pod.config.CgroupParent), ".slice") {
-			return nil, errors.Wrapf(ErrInvalidArg, "did not receive systemd slice as cgroup parent when using systemd to manage cgroups")
 		}
 		// If we are set to use pod cgroups, set the cgroup parent that
 		// all containers in the pod will share
@@ -75,17 +76,57 @@ func (r *Runtime) NewPod(options ...PodCreateOption) (*Pod, error) {
 		if pod.config.UsePodCgroup {
 			pod.state.CgroupPath = filepath.Join(pod.config.CgroupParent, pod.ID())
 		}
-	default:
-		return nil, errors.Wrapf(ErrInvalidArg, "unsupported CGroup manager: %s - cannot validate cgroup parent", r.config.CgroupManager)
+	}
+
+	// Create the directories backing the pod's shared volumes
+	for _, vol := range pod.config.Volumes {
+		if err := os.MkdirAll(vol.Source, 0755); err != nil {
+			return nil, errors.Wrapf(err, "error creating directory for pod volume %s", vol.Name)
+		}
 	}
 
 	if err := r.state.AddPod(pod); err != nil {
 		return nil, errors.Wrapf(err, "error adding pod to state")
 	}
 
+	publishPodEvent(pod.ID(), PodEventCreate, "", "")
+
 	return nil, ErrNotImplemented
 }
 
+// ValidatePodCgroupParent checks whether a cgroup parent would be accepted for
+// a new pod by the runtime's cgroup manager, without creating a pod.  When
+// systemd manages cgroups, the parent must be a slice; when cgroupfs does, it
+// must not be.  An empty parent is valid, as the manager's default is used.
+func (r *Runtime) ValidatePodCgroupParent(parent string) error {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if !r.valid {
+		return ErrRuntimeStopped
+	}
+
+	return r.validatePodCgroupParent(parent)
+}
+
+// validatePodCgroupParent checks a pod's cgroup parent against the runtime's
+// cgroup manager
+func (r *Runtime) validatePodCgroupParent(parent string) error {
+	switch r.config.CgroupManager {
+	case CgroupfsCgroupsManager:
+		if parent != "" && strings.HasSuffix(path.Base(parent), ".slice") {
+			return errors.Wrapf(ErrInvalidArg, "systemd slice received as cgroup parent when using cgroupfs")
+		}
+	case SystemdCgroupsManager:
+		if parent != "" && (len(parent) < 6 || !strings.HasSuffix(path.Base(parent), ".slice")) {
+			return errors.Wrapf(ErrInvalidArg, "did not receive systemd slice as cgroup parent when using systemd to manage cgroups")
+		}
+	default:
+		return errors.Wrapf(ErrInvalidArg, "unsupported CGroup manager: %s - cannot validate cgroup parent", r.config.CgroupManager)
+	}
+	return nil
+}
+
 // RemovePod removes a pod
 // If removeCtrs is specified, containers will be removed
 // Otherwise, a pod that is not empty will return an error and not be removed
@@ -251,9 +292,18 @@ func (r *Runtime) RemovePod(ctx context.Context, p *Pod, removeCtrs, force bool)
 		return err
 	}
 
+	// Remove the pod's shared volumes
+	if len(p.config.Volumes) > 0 {
+		if err := os.RemoveAll(filepath.Dir(p.volumesDir())); err != nil {
+			logrus.Errorf("Error removing volumes of pod %s: %v", p.ID(), err)
+		}
+	}
+
 	// Mark pod invalid
 	p.valid = false
 
+	publishPodEvent(p.ID(), PodEventRemove, "", "")
+
 	return nil
 }
 
@@ -325,3 +375,94 @@ func (r *Runtime) Pods(filters ...PodFilter) ([]*Pod, error) {
 
 	return podsFiltered, nil
 }
+
+// OrphanedPod is a pod whose state is inconsistent with the containers and
+// cgroups on the host, as found by ListOrphanedPods
+type OrphanedPod struct {
+	Pod *Pod
+	// Problems describes each inconsistency found
+	Problems []string
+}
+
+// ListOrphanedPods checks every pod in the state and returns those which are
+// inconsistent, such as pods whose containers are missing from the state or
+// from storage, or whose running containers' cgroup no longer exists.  This
+// typically happens after an unclean shutdown.  Pods are not changed; the
+// problems found are reported so that the pods can be cleaned up.
+func (r *Runtime) ListOrphanedPods() ([]*OrphanedPod, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if !r.valid {
+		return nil, ErrRuntimeStopped
+	}
+
+	pods, err := r.state.AllPods()
+	if err != nil {
+		return nil, err
+	}
+
+	var orphaned []*OrphanedPod
+	for _, pod := range pods {
+		problems := r.checkPod(pod)
+		if len(problems) > 0 {
+			orphaned = append(orphaned, &OrphanedPod{
+				Pod:      pod,
+				Problems: problems,
+			})
+		}
+	}
+	return orphaned, nil
+}
+
+// checkPod returns the inconsistencies found in a pod
+func (r *Runtime) checkPod(pod *Pod) []string {
+	ctrIDs, err := r.state.PodContainersByID(pod)
+	if err != nil {
+		return []string{fmt.Sprintf("unable to list containers: %v", err)}
+	}
+
+	problems, ctrs := checkPodContainers(pod, ctrIDs, r.state.Container)
+
+	active := false
+	for _, ctr := range ctrs {
+		if _, err := r.store.Container(ctr.ID()); err != nil {
+			problems = append(problems, fmt.Sprintf("storage of container %s is missing: %v", ctr.ID(), err))
+		}
+		if ctr.state.State == ContainerStateRunning || ctr.state.State == ContainerStatePaused {
+			active = true
+		}
+	}
+
+	// The pod's cgroup is created when its first container starts, so it
+	// should only exist while containers are running
+	if active && pod.state.CgroupPath != "" && r.config.CgroupManager == CgroupfsCgroupsManager {
+		if _, err := cgroups.Load(cgroups.V1, cgroups.StaticPath(pod.state.CgroupPath)); err != nil {
+			problems = append(problems, fmt.Sprintf("cgroup %s is missing: %v", pod.state.CgroupPath, err))
+		}
+	}
+
+	return problems
+}
+
+// checkPodContainers looks up the containers of a pod, returning the
+// inconsistencies found and the containers which could be retrieved
+func checkPodContainers(pod *Pod, ctrIDs []string, lookup func(id string) (*Container, error)) ([]string, []*Container) {
+	var problems []string
+	var ctrs []*Container
+	for _, id := range ctrIDs {
+		ctr, err := lookup(id)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("container %s is missing from the state: %v", id, err))
+			continue
+		}
+		ctrs = append(ctrs, ctr)
+		if ctr.PodID() != pod.ID() {
+			problems = append(problems, fmt.Sprintf("container %s belongs to pod %q", id, ctr.PodID()))
+		}
+		if ctr.state.State == ContainerStateUnknown {
+			problems = append(problems, fmt.Sprintf("container %s is in an unknown state", id))
+		}
+	}
+	return problems, ctrs
+}
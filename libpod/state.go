@@ -28,6 +28,12 @@ type State interface {
 	UpdateContainer(ctr *Container) error
 	// SaveContainer saves a container's current state to the backing store
 	SaveContainer(ctr *Container) error
+	// RewriteContainerConfig replaces a container's configuration in the
+	// backing store, and in the container, with the given configuration
+	// Configurations are otherwise immutable once a container is created;
+	// the caller must ensure the new configuration is safe to use with
+	// the container in its current state
+	RewriteContainerConfig(ctr *Container, newCfg *ContainerConfig) error
 	// ContainerInUse checks if other containers depend upon a given
 	// container
 	// It returns a slice of the IDs of containers which depend on the given
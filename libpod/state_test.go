@@ -502,6 +502,53 @@ func TestSaveContainerNotInStateReturnsError(t *testing.T) {
 	})
 }
 
+func TestRewriteContainerConfig(t *testing.T) {
+	runForAllStates(t, func(t *testing.T, state State, lockPath string) {
+		testCtr, err := getTestCtr1(lockPath)
+		assert.NoError(t, err)
+
+		err = state.AddContainer(testCtr)
+		assert.NoError(t, err)
+
+		newCfg := *testCtr.config
+		newCfg.MountLabel = "system_u:object_r:container_file_t:s0:c1,c2"
+		err = state.RewriteContainerConfig(testCtr, &newCfg)
+		assert.NoError(t, err)
+		assert.Equal(t, newCfg.MountLabel, testCtr.MountLabel())
+
+		retrievedCtr, err := state.Container(testCtr.ID())
+		assert.NoError(t, err)
+		assert.Equal(t, newCfg.MountLabel, retrievedCtr.MountLabel())
+	})
+}
+
+func TestRewriteContainerConfigCannotChangeID(t *testing.T) {
+	runForAllStates(t, func(t *testing.T, state State, lockPath string) {
+		testCtr, err := getTestCtr1(lockPath)
+		assert.NoError(t, err)
+
+		err = state.AddContainer(testCtr)
+		assert.NoError(t, err)
+
+		newCfg := *testCtr.config
+		newCfg.ID = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+		err = state.RewriteContainerConfig(testCtr, &newCfg)
+		assert.Error(t, err)
+	})
+}
+
+func TestRewriteContainerConfigNotInStateReturnsError(t *testing.T) {
+	runForAllStates(t, func(t *testing.T, state State, lockPath string) {
+		testCtr, err := getTestCtr1(lockPath)
+		assert.NoError(t, err)
+
+		newCfg := *testCtr.config
+		err = state.RewriteContainerConfig(testCtr, &newCfg)
+		assert.Error(t, err)
+		assert.False(t, testCtr.valid)
+	})
+}
+
 func TestRemoveContainer(t *testing.T) {
 	runForAllStates(t, func(t *testing.T, state State, lockPath string) {
 		testCtr, err := getTestCtr1(lockPath)
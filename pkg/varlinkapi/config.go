@@ -1,6 +1,9 @@
 package varlinkapi
 
 import (
+	"context"
+	"sync"
+
 	ioprojectatomicpodman "github.com/projectatomic/libpod/cmd/podman/varlink"
 	"github.com/urfave/cli"
 )
@@ -9,10 +12,21 @@ import (
 type LibpodAPI struct {
 	Cli *cli.Context
 	ioprojectatomicpodman.VarlinkInterface
+	// builds maps the IDs of in-progress builds to the functions which
+	// cancel them
+	builds map[string]context.CancelFunc
+	// buildLogs maps the IDs of builds, finished or not, to the files
+	// their logs were written to
+	buildLogs map[string]string
+	buildLock sync.Mutex
 }
 
 // New creates a new varlink client
 func New(cli *cli.Context) *ioprojectatomicpodman.VarlinkInterface {
-	lp := LibpodAPI{Cli: cli}
+	lp := LibpodAPI{
+		Cli:       cli,
+		builds:    make(map[string]context.CancelFunc),
+		buildLogs: make(map[string]string),
+	}
 	return ioprojectatomicpodman.VarlinkNew(&lp)
 }
@@ -123,6 +123,63 @@ func (i *LibpodAPI) ListContainerProcesses(call ioprojectatomicpodman.VarlinkCal
 	return call.ReplyListContainerProcesses(psOutput)
 }
 
+// attachWriter streams what is written to it to a varlink client as the output
+// of AttachContainer
+type attachWriter struct {
+	call   ioprojectatomicpodman.VarlinkCall
+	stream string
+}
+
+func (w *attachWriter) Write(p []byte) (int, error) {
+	// Replying fails once the client has gone away, which ends the attach
+	if err := w.call.ReplyAttachContainer(ioprojectatomicpodman.AttachOutput{
+		Stream: w.stream,
+		Data:   string(p),
+	}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *attachWriter) Close() error {
+	return nil
+}
+
+// AttachContainer streams the output of a container
+func (i *LibpodAPI) AttachContainer(call ioprojectatomicpodman.VarlinkCall, name string, stdin bool) error {
+	if !call.WantsMore() {
+		return call.ReplyErrorOccurred("AttachContainer must be called with the more flag")
+	}
+	if stdin {
+		return call.ReplyErrorOccurred("attaching stdin is not supported over varlink")
+	}
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	ctr, err := runtime.LookupContainer(name)
+	if err != nil {
+		return call.ReplyContainerNotFound(name)
+	}
+
+	// The output is multiplexed by conmon, which sends all the output of
+	// a container with a terminal as stdout
+	streams := &libpod.AttachStreams{
+		OutputStream: &attachWriter{call: call, stream: "stdout"},
+		ErrorStream:  &attachWriter{call: call, stream: "stderr"},
+		AttachOutput: true,
+		AttachError:  true,
+	}
+	call.Continues = true
+	err = ctr.Attach(streams, "", nil)
+	call.Continues = false
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	// The container has exited; end the stream
+	return call.ReplyAttachContainer(ioprojectatomicpodman.AttachOutput{Stream: "stdout"})
+}
+
 // GetContainerLogs ...
 func (i *LibpodAPI) GetContainerLogs(call ioprojectatomicpodman.VarlinkCall, name string) error {
 	var logs []string
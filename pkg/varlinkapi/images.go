@@ -1,16 +1,22 @@
 package varlinkapi
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"bytes"
 	"github.com/containers/image/docker"
 	"github.com/containers/image/types"
+	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/go-units"
 	"github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/opencontainers/runtime-spec/specs-go"
@@ -23,6 +29,7 @@ import (
 	"github.com/projectatomic/libpod/libpod/image"
 	sysreg "github.com/projectatomic/libpod/pkg/registries"
 	"github.com/projectatomic/libpod/pkg/util"
+	"github.com/sirupsen/logrus"
 )
 
 // ListImages lists all the images in the store
@@ -38,26 +45,101 @@ func (i *LibpodAPI) ListImages(call ioprojectatomicpodman.VarlinkCall) error {
 	}
 	var imageList []ioprojectatomicpodman.ImageInList
 	for _, image := range images {
-		labels, _ := image.Labels(getContext())
-		containers, _ := image.Containers()
-		size, _ := image.Size(getContext())
-
-		i := ioprojectatomicpodman.ImageInList{
-			Id:          image.ID(),
-			ParentId:    image.Parent,
-			RepoTags:    image.Names(),
-			RepoDigests: image.RepoDigests(),
-			Created:     image.Created().String(),
-			Size:        int64(*size),
-			VirtualSize: image.VirtualSize,
-			Containers:  int64(len(containers)),
-			Labels:      labels,
-		}
-		imageList = append(imageList, i)
+		imageList = append(imageList, makeImageInList(image))
 	}
 	return call.ReplyListImages(imageList)
 }
 
+// ListImagesByMediaType lists the images whose manifest has the given media type
+func (i *LibpodAPI) ListImagesByMediaType(call ioprojectatomicpodman.VarlinkCall, mediaType string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	filter, err := image.ManifestTypeFilter(getContext(), mediaType)
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	images, err := runtime.ImageRuntime().GetImages()
+	if err != nil {
+		return call.ReplyErrorOccurred(fmt.Sprintf("unable to get list of images %q", err))
+	}
+	var imageList []ioprojectatomicpodman.ImageInList
+	for _, img := range image.FilterImages(images, []image.ResultFilter{filter}) {
+		imageList = append(imageList, makeImageInList(img))
+	}
+	return call.ReplyListImagesByMediaType(imageList)
+}
+
+// GetImageByLabel lists the images whose label key is set to value
+func (i *LibpodAPI) GetImageByLabel(call ioprojectatomicpodman.VarlinkCall, key, value string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	images, err := runtime.ImageRuntime().GetImagesByLabel(getContext(), key, value)
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	imageList := []ioprojectatomicpodman.ImageInList{}
+	for _, img := range images {
+		imageList = append(imageList, makeImageInList(img))
+	}
+	return call.ReplyGetImageByLabel(imageList)
+}
+
+// ListForeignArchImages lists the images built for an architecture other than the host's
+func (i *LibpodAPI) ListForeignArchImages(call ioprojectatomicpodman.VarlinkCall) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	images, err := runtime.ImageRuntime().ForeignArchImages(getContext())
+	if err != nil {
+		return call.ReplyErrorOccurred(fmt.Sprintf("unable to get list of images %q", err))
+	}
+	imageList := []ioprojectatomicpodman.ImageInList{}
+	for _, img := range images {
+		imageList = append(imageList, makeImageInList(img))
+	}
+	return call.ReplyListForeignArchImages(imageList)
+}
+
+// FindImagesContainingPath lists the images whose filesystem contains a path
+func (i *LibpodAPI) FindImagesContainingPath(call ioprojectatomicpodman.VarlinkCall, path, nameGlob string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	images, err := runtime.ImageRuntime().FindImagesContainingPath(getContext(), path, nameGlob)
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	if images == nil {
+		images = []string{}
+	}
+	return call.ReplyFindImagesContainingPath(images)
+}
+
+// makeImageInList converts an image to the ImageInList returned by ListImages
+func makeImageInList(img *image.Image) ioprojectatomicpodman.ImageInList {
+	labels, _ := img.Labels(getContext())
+	containers, _ := img.Containers()
+	size, _ := img.Size(getContext())
+
+	return ioprojectatomicpodman.ImageInList{
+		Id:          img.ID(),
+		ParentId:    img.Parent,
+		RepoTags:    img.Names(),
+		RepoDigests: img.RepoDigests(),
+		Created:     img.Created().String(),
+		Size:        int64(*size),
+		VirtualSize: img.VirtualSize,
+		Containers:  int64(len(containers)),
+		Labels:      labels,
+	}
+}
+
 // GetImage returns a single image in the form of a ImageInList
 func (i *LibpodAPI) GetImage(call ioprojectatomicpodman.VarlinkCall, name string) error {
 	runtime, err := libpodruntime.GetRuntime(i.Cli)
@@ -110,26 +192,9 @@ func (i *LibpodAPI) BuildImage(call ioprojectatomicpodman.VarlinkCall, config io
 	defer runtime.Shutdown(false)
 
 	systemContext := types.SystemContext{}
-	dockerfiles := config.Dockerfile
-	contextDir := ""
-
-	for i := range dockerfiles {
-		if strings.HasPrefix(dockerfiles[i], "http://") ||
-			strings.HasPrefix(dockerfiles[i], "https://") ||
-			strings.HasPrefix(dockerfiles[i], "git://") ||
-			strings.HasPrefix(dockerfiles[i], "github.com/") {
-			continue
-		}
-		absFile, err := filepath.Abs(dockerfiles[i])
-		if err != nil {
-			return errors.Wrapf(err, "error determining path to file %q", dockerfiles[i])
-		}
-		contextDir = filepath.Dir(absFile)
-		dockerfiles[i], err = filepath.Rel(contextDir, absFile)
-		if err != nil {
-			return errors.Wrapf(err, "error determining path to file %q", dockerfiles[i])
-		}
-		break
+	contextDir, dockerfiles, err := dockerfilesContext(config.Dockerfile)
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
 	}
 
 	pullPolicy := imagebuildah.PullNever
@@ -168,6 +233,14 @@ func (i *LibpodAPI) BuildImage(call ioprojectatomicpodman.VarlinkCall, config io
 		}
 	}
 
+	var maxSize int64
+	if config.Max_size != "" {
+		maxSize, err = units.RAMInBytes(config.Max_size)
+		if err != nil {
+			return call.ReplyErrorOccurred(err.Error())
+		}
+	}
+
 	output := bytes.NewBuffer([]byte{})
 	commonOpts := &buildah.CommonBuildOptions{
 		AddHost:      config.Add_hosts,
@@ -215,7 +288,38 @@ func (i *LibpodAPI) BuildImage(call ioprojectatomicpodman.VarlinkCall, config io
 		call.Continues = true
 	}
 
-	c := build(runtime, options, config.Dockerfile)
+	// buildErr records why the build failed, if it did
+	var buildErr error
+	buildID := stringid.GenerateNonCryptoID()
+	if config.Log_file != "" {
+		logFile, err := createBuildLog(config.Log_file)
+		if err != nil {
+			return call.ReplyErrorOccurred(err.Error())
+		}
+		defer func() {
+			if err := logFile.Sync(); err != nil {
+				logrus.Errorf("unable to flush log of build %s to %s: %q", buildID, config.Log_file, err)
+			}
+			logFile.Close()
+		}()
+		options.ReportWriter = io.MultiWriter(output, logFile)
+		i.buildLock.Lock()
+		i.buildLogs[buildID] = config.Log_file
+		i.buildLock.Unlock()
+		// Record why a build failed in its log, as the client may no
+		// longer be around to receive the error
+		defer func() {
+			if buildErr != nil {
+				fmt.Fprintf(logFile, "build %s failed: %v\n", buildID, buildErr)
+			}
+		}()
+	}
+
+	ctx, cancel := context.WithCancel(getContext())
+	i.trackBuild(buildID, cancel)
+	defer i.untrackBuild(buildID)
+
+	c := build(ctx, runtime, options, dockerfiles)
 	var log []string
 	done := false
 	for {
@@ -226,7 +330,12 @@ func (i *LibpodAPI) BuildImage(call ioprojectatomicpodman.VarlinkCall, config io
 		} else if err == io.EOF {
 			select {
 			case err := <-c:
+				if ctx.Err() == context.Canceled {
+					buildErr = errors.Errorf("build %s was cancelled", buildID)
+					return call.ReplyErrorOccurred(buildErr.Error())
+				}
 				if err != nil {
+					buildErr = err
 					return call.ReplyErrorOccurred(err.Error())
 				}
 				done = true
@@ -236,12 +345,15 @@ func (i *LibpodAPI) BuildImage(call ioprojectatomicpodman.VarlinkCall, config io
 					break
 				}
 				br := ioprojectatomicpodman.BuildResponse{
-					Logs: log,
+					Logs:     log,
+					Build_id: buildID,
+					Log_file: config.Log_file,
 				}
 				call.ReplyBuildImage(br)
 				log = []string{}
 			}
 		} else {
+			buildErr = err
 			return call.ReplyErrorOccurred(err.Error())
 		}
 		if done {
@@ -251,19 +363,77 @@ func (i *LibpodAPI) BuildImage(call ioprojectatomicpodman.VarlinkCall, config io
 	call.Continues = false
 	newImage, err := runtime.ImageRuntime().NewFromLocal(config.Tags[0])
 	if err != nil {
+		buildErr = err
 		return call.ReplyErrorOccurred(err.Error())
 	}
+	if maxSize > 0 {
+		if err := enforceImageSizeLimit(getContext(), newImage, maxSize); err != nil {
+			buildErr = err
+			return call.ReplyErrorOccurred(err.Error())
+		}
+	}
 	br := ioprojectatomicpodman.BuildResponse{
-		Logs: log,
-		Id:   newImage.ID(),
+		Logs:     log,
+		Id:       newImage.ID(),
+		Build_id: buildID,
+		Log_file: config.Log_file,
 	}
 	return call.ReplyBuildImage(br)
 }
 
-func build(runtime *libpod.Runtime, options imagebuildah.BuildOptions, dockerfiles []string) chan error {
+// enforceImageSizeLimit removes a built image which is larger than maxSize
+// bytes, and returns an error giving its size and the limit
+func enforceImageSizeLimit(ctx context.Context, img *image.Image, maxSize int64) error {
+	size, err := img.Size(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "unable to determine size of image %s", img.ID())
+	}
+	if *size <= uint64(maxSize) {
+		return nil
+	}
+	if err := img.Remove(false); err != nil {
+		return errors.Wrapf(err, "image %s is %s, larger than the maximum size of %s, and could not be removed", img.ID(), units.HumanSize(float64(*size)), units.HumanSize(float64(maxSize)))
+	}
+	return errors.Errorf("image %s is %s (%d bytes), larger than the maximum size of %s (%d bytes), and was removed", img.ID(), units.HumanSize(float64(*size)), *size, units.HumanSize(float64(maxSize)), maxSize)
+}
+
+// dockerfilesContext determines the context directory of a build from the
+// local Dockerfiles among dockerfiles, which must all be in the same
+// directory, and returns the Dockerfiles with the local ones made relative to
+// it.  Remote Dockerfiles are returned unchanged.
+func dockerfilesContext(dockerfiles []string) (string, []string, error) {
+	contextDir := ""
+	relDockerfiles := make([]string, 0, len(dockerfiles))
+	for _, dockerfile := range dockerfiles {
+		if strings.HasPrefix(dockerfile, "http://") ||
+			strings.HasPrefix(dockerfile, "https://") ||
+			strings.HasPrefix(dockerfile, "git://") ||
+			strings.HasPrefix(dockerfile, "github.com/") {
+			relDockerfiles = append(relDockerfiles, dockerfile)
+			continue
+		}
+		absFile, err := filepath.Abs(dockerfile)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "error determining path to file %q", dockerfile)
+		}
+		if contextDir == "" {
+			contextDir = filepath.Dir(absFile)
+		} else if filepath.Dir(absFile) != contextDir {
+			return "", nil, errors.Errorf("Dockerfile %q is not in the same directory as the other Dockerfiles, %q", dockerfile, contextDir)
+		}
+		relFile, err := filepath.Rel(contextDir, absFile)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "error determining path to file %q", dockerfile)
+		}
+		relDockerfiles = append(relDockerfiles, relFile)
+	}
+	return contextDir, relDockerfiles, nil
+}
+
+func build(ctx context.Context, runtime *libpod.Runtime, options imagebuildah.BuildOptions, dockerfiles []string) chan error {
 	c := make(chan error)
 	go func() {
-		err := runtime.Build(getContext(), options, dockerfiles...)
+		err := runtime.Build(ctx, options, dockerfiles...)
 		c <- err
 		close(c)
 	}()
@@ -271,6 +441,90 @@ func build(runtime *libpod.Runtime, options imagebuildah.BuildOptions, dockerfil
 	return c
 }
 
+// trackBuild records the cancel function of an in-progress build
+func (i *LibpodAPI) trackBuild(buildID string, cancel context.CancelFunc) {
+	i.buildLock.Lock()
+	defer i.buildLock.Unlock()
+	i.builds[buildID] = cancel
+}
+
+// untrackBuild forgets a build once it has finished and releases its context
+func (i *LibpodAPI) untrackBuild(buildID string) {
+	i.buildLock.Lock()
+	defer i.buildLock.Unlock()
+	if cancel, ok := i.builds[buildID]; ok {
+		cancel()
+		delete(i.builds, buildID)
+	}
+}
+
+// createBuildLog creates the file a build log is written to.  The file must
+// not already exist, so that an existing file or a symlink planted in its place
+// is never written through, and is only readable by its owner.
+func createBuildLog(path string) (*os.File, error) {
+	if !filepath.IsAbs(path) {
+		return nil, errors.Errorf("build log file %q must be an absolute path", path)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create build log file %q", path)
+	}
+	return f, nil
+}
+
+// GetBuildLog returns the log of a build which was written to a file
+func (i *LibpodAPI) GetBuildLog(call ioprojectatomicpodman.VarlinkCall, buildID string) error {
+	i.buildLock.Lock()
+	logFile, ok := i.buildLogs[buildID]
+	i.buildLock.Unlock()
+	if !ok {
+		return call.ReplyErrorOccurred(fmt.Sprintf("no log file is known for build %s", buildID))
+	}
+	content, err := ioutil.ReadFile(logFile)
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	logs := []string{}
+	if len(content) > 0 {
+		logs = strings.SplitAfter(strings.TrimSuffix(string(content), "\n"), "\n")
+	}
+	return call.ReplyGetBuildLog(logFile, logs)
+}
+
+// GetDockerfileArgs returns the build arguments declared by a Dockerfile
+func (i *LibpodAPI) GetDockerfileArgs(call ioprojectatomicpodman.VarlinkCall, content string) error {
+	args, err := image.GetDockerfileArgs(content)
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	dockerfileArgs := make([]ioprojectatomicpodman.DockerfileArg, 0, len(args))
+	for _, arg := range args {
+		dockerfileArgs = append(dockerfileArgs, ioprojectatomicpodman.DockerfileArg{
+			Name:        arg.Name,
+			Default:     arg.Default,
+			Has_default: arg.HasDefault,
+			Global:      arg.Global,
+			Stage:       int64(arg.Stage),
+			Stage_name:  arg.StageName,
+		})
+	}
+	return call.ReplyGetDockerfileArgs(dockerfileArgs)
+}
+
+// CancelBuild cancels the context of an in-progress build.  Buildah removes the
+// build's working container once the current instruction returns.  It returns
+// false if no build with the given ID is in progress.
+func (i *LibpodAPI) CancelBuild(call ioprojectatomicpodman.VarlinkCall, buildID string) error {
+	i.buildLock.Lock()
+	cancel, ok := i.builds[buildID]
+	i.buildLock.Unlock()
+	if !ok {
+		return call.ReplyCancelBuild(false)
+	}
+	cancel()
+	return call.ReplyCancelBuild(true)
+}
+
 // CreateImage ...
 // TODO With Pull being added, should we skip Create?
 func (i *LibpodAPI) CreateImage(call ioprojectatomicpodman.VarlinkCall) error {
@@ -334,6 +588,114 @@ func (i *LibpodAPI) HistoryImage(call ioprojectatomicpodman.VarlinkCall, name st
 	return call.ReplyHistoryImage(histories)
 }
 
+// ScrubImageHistory creates a copy of an image without the commands recorded in its history
+func (i *LibpodAPI) ScrubImageHistory(call ioprojectatomicpodman.VarlinkCall, name, newName string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	newImage, err := runtime.ImageRuntime().NewFromLocal(name)
+	if err != nil {
+		return call.ReplyImageNotFound(name)
+	}
+	scrubbed, err := newImage.ScrubHistory(getContext(), newName)
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplyScrubImageHistory(scrubbed.ID())
+}
+
+// ExportImageLayers writes an image to a directory, skipping layers which are
+// already there
+func (i *LibpodAPI) ExportImageLayers(call ioprojectatomicpodman.VarlinkCall, name string, excludeDigests []string, destination string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	newImage, err := runtime.ImageRuntime().NewFromLocal(name)
+	if err != nil {
+		return call.ReplyImageNotFound(name)
+	}
+	written, err := newImage.ExportLayers(getContext(), excludeDigests, destination)
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	layers := []string{}
+	for _, d := range written {
+		layers = append(layers, d.String())
+	}
+	return call.ReplyExportImageLayers(layers)
+}
+
+// SetImageMetadata sets local metadata on an image
+func (i *LibpodAPI) SetImageMetadata(call ioprojectatomicpodman.VarlinkCall, name string, metadata map[string]string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	newImage, err := runtime.ImageRuntime().NewFromLocal(name)
+	if err != nil {
+		return call.ReplyImageNotFound(name)
+	}
+	if err := newImage.SetLocalMetadata(metadata); err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplySetImageMetadata(newImage.ID())
+}
+
+// GetImageMetadata returns the local metadata of an image
+func (i *LibpodAPI) GetImageMetadata(call ioprojectatomicpodman.VarlinkCall, name string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	newImage, err := runtime.ImageRuntime().NewFromLocal(name)
+	if err != nil {
+		return call.ReplyImageNotFound(name)
+	}
+	metadata, err := newImage.LocalMetadata()
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplyGetImageMetadata(metadata)
+}
+
+// PatchImageConfig creates a new image with the configuration of an image
+// changed by a patch
+func (i *LibpodAPI) PatchImageConfig(call ioprojectatomicpodman.VarlinkCall, name, newName string, patch ioprojectatomicpodman.ImageConfigPatch) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	newImage, err := runtime.ImageRuntime().NewFromLocal(name)
+	if err != nil {
+		return call.ReplyImageNotFound(name)
+	}
+	configPatch := image.ImageConfigPatch{
+		AddEnv:      patch.Add_env,
+		RemoveEnv:   patch.Remove_env,
+		SetLabels:   patch.Set_labels,
+		UnsetLabels: patch.Unset_labels,
+		User:        patch.User,
+		WorkDir:     patch.Work_dir,
+		AddPorts:    patch.Add_ports,
+		RemovePorts: patch.Remove_ports,
+	}
+	// A present but empty entrypoint or command clears it, so it must not
+	// be confused with an absent one
+	if patch.Entrypoint != nil {
+		configPatch.Entrypoint = append([]string{}, *patch.Entrypoint...)
+	}
+	if patch.Cmd != nil {
+		configPatch.Cmd = append([]string{}, *patch.Cmd...)
+	}
+	patched, err := newImage.PatchConfig(getContext(), newName, configPatch)
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplyPatchImageConfig(patched.ID())
+}
+
 // PushImage pushes an local image to registry
 // TODO We need to add options for signing, credentials, tls, and multi-tag
 func (i *LibpodAPI) PushImage(call ioprojectatomicpodman.VarlinkCall, name, tag string, tlsVerify bool) error {
@@ -362,6 +724,71 @@ func (i *LibpodAPI) PushImage(call ioprojectatomicpodman.VarlinkCall, name, tag
 	return call.ReplyPushImage(newImage.ID())
 }
 
+// GetImageBuildArgs returns the build arguments recorded in an image's history
+func (i *LibpodAPI) GetImageBuildArgs(call ioprojectatomicpodman.VarlinkCall, name string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	newImage, err := runtime.ImageRuntime().NewFromLocal(name)
+	if err != nil {
+		return call.ReplyImageNotFound(name)
+	}
+	args, err := newImage.BuildArgs(getContext())
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplyGetImageBuildArgs(args)
+}
+
+// GetImageWorkingDir returns the directory a container of an image starts in
+func (i *LibpodAPI) GetImageWorkingDir(call ioprojectatomicpodman.VarlinkCall, name string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	newImage, err := runtime.ImageRuntime().NewFromLocal(name)
+	if err != nil {
+		return call.ReplyImageNotFound(name)
+	}
+	workingDir, err := newImage.WorkingDir(getContext())
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplyGetImageWorkingDir(workingDir)
+}
+
+// GetImageStorageInfo returns how the storage driver keeps an image
+func (i *LibpodAPI) GetImageStorageInfo(call ioprojectatomicpodman.VarlinkCall, name string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	newImage, err := runtime.ImageRuntime().NewFromLocal(name)
+	if err != nil {
+		return call.ReplyImageNotFound(name)
+	}
+	info, err := newImage.StorageInfo()
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	layers := make([]ioprojectatomicpodman.LayerStorageInfo, 0, len(info.Layers))
+	for _, layer := range info.Layers {
+		layers = append(layers, ioprojectatomicpodman.LayerStorageInfo{
+			Id:          layer.ID,
+			Location:    layer.Location,
+			Driver_data: layer.DriverData,
+			Size:        layer.Size,
+		})
+	}
+	return call.ReplyGetImageStorageInfo(ioprojectatomicpodman.ImageStorageInfo{
+		Driver:         info.Driver,
+		Driver_options: info.DriverOptions,
+		Layers:         layers,
+		Size:           info.Size,
+	})
+}
+
 // TagImage accepts an image name and tag as strings and tags an image in the local store.
 func (i *LibpodAPI) TagImage(call ioprojectatomicpodman.VarlinkCall, name, tag string) error {
 	runtime, err := libpodruntime.GetRuntime(i.Cli)
@@ -378,6 +805,24 @@ func (i *LibpodAPI) TagImage(call ioprojectatomicpodman.VarlinkCall, name, tag s
 	return call.ReplyTagImage(newImage.ID())
 }
 
+// ReplaceImage moves the tags of an image to another image
+func (i *LibpodAPI) ReplaceImage(call ioprojectatomicpodman.VarlinkCall, name, newImageID string, removeOld bool) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	for _, imageName := range []string{name, newImageID} {
+		if _, err := runtime.ImageRuntime().NewFromLocal(imageName); err != nil {
+			return call.ReplyImageNotFound(imageName)
+		}
+	}
+	tags, err := runtime.ImageRuntime().ReplaceImage(name, newImageID, removeOld)
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplyReplaceImage(tags)
+}
+
 // RemoveImage accepts a image name or ID as a string and force bool to determine if it should
 // remove the image even if being used by stopped containers
 func (i *LibpodAPI) RemoveImage(call ioprojectatomicpodman.VarlinkCall, name string, force bool) error {
@@ -452,6 +897,58 @@ func (i *LibpodAPI) DeleteUnusedImages(call ioprojectatomicpodman.VarlinkCall) e
 	return call.ReplyDeleteUnusedImages(deletedImages)
 }
 
+// PruneImagesKeepRecent removes all but the most recent images of each repository
+func (i *LibpodAPI) PruneImagesKeepRecent(call ioprojectatomicpodman.VarlinkCall, keep int64, filters []string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	reports, err := runtime.ImageRuntime().PruneImagesKeepRecent(getContext(), int(keep), filters)
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	repositories := make(map[string]ioprojectatomicpodman.PrunedImages, len(reports))
+	for repo, report := range reports {
+		repositories[repo] = ioprojectatomicpodman.PrunedImages{
+			Removed: report.Removed,
+			Size:    int64(report.Size),
+		}
+	}
+	return call.ReplyPruneImagesKeepRecent(repositories)
+}
+
+// SetImageExpiry sets the time an image expires at
+func (i *LibpodAPI) SetImageExpiry(call ioprojectatomicpodman.VarlinkCall, name string, expiresAt int64) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	newImage, err := runtime.ImageRuntime().NewFromLocal(name)
+	if err != nil {
+		return call.ReplyImageNotFound(name)
+	}
+	if err := runtime.ImageRuntime().SetImageExpiry(newImage.ID(), expiresAt); err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplySetImageExpiry(newImage.ID())
+}
+
+// PruneExpiredImages removes the images whose expiry has passed
+func (i *LibpodAPI) PruneExpiredImages(call ioprojectatomicpodman.VarlinkCall) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	report, err := runtime.ImageRuntime().PruneExpiredImages(getContext())
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplyPruneExpiredImages(ioprojectatomicpodman.PrunedImages{
+		Removed: report.Removed,
+		Size:    int64(report.Size),
+	})
+}
+
 // Commit ...
 func (i *LibpodAPI) Commit(call ioprojectatomicpodman.VarlinkCall, name, imageName string, changes []string, author, message string, pause bool) error {
 	runtime, err := libpodruntime.GetRuntime(i.Cli)
@@ -484,6 +981,38 @@ func (i *LibpodAPI) Commit(call ioprojectatomicpodman.VarlinkCall, name, imageNa
 	return call.ReplyCommit(newImage.ID())
 }
 
+// CommitToNames commits a container to an image with several names
+func (i *LibpodAPI) CommitToNames(call ioprojectatomicpodman.VarlinkCall, name string, imageNames, changes []string, author, message string, pause bool) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	ctr, err := runtime.LookupContainer(name)
+	if err != nil {
+		return call.ReplyContainerNotFound(name)
+	}
+	sc := image.GetSystemContext(runtime.GetConfig().SignaturePolicyPath, "", false)
+	coptions := buildah.CommitOptions{
+		SignaturePolicyPath:   runtime.GetConfig().SignaturePolicyPath,
+		ReportWriter:          nil,
+		SystemContext:         sc,
+		PreferredManifestType: buildah.OCIv1ImageManifest,
+	}
+	options := libpod.ContainerCommitOptions{
+		CommitOptions: coptions,
+		Pause:         pause,
+		Message:       message,
+		Changes:       changes,
+		Author:        author,
+	}
+
+	newImage, err := ctr.CommitToNames(getContext(), imageNames, options)
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplyCommitToNames(newImage.ID(), newImage.Names())
+}
+
 // ImportImage imports an image from a tarball to the image store
 func (i *LibpodAPI) ImportImage(call ioprojectatomicpodman.VarlinkCall, source, reference, message string, changes []string) error {
 	runtime, err := libpodruntime.GetRuntime(i.Cli)
@@ -510,7 +1039,7 @@ func (i *LibpodAPI) ImportImage(call ioprojectatomicpodman.VarlinkCall, source,
 
 // ExportImage exports an image to the provided destination
 // destination must have the transport type!!
-func (i *LibpodAPI) ExportImage(call ioprojectatomicpodman.VarlinkCall, name, destination string, compress bool, tags []string) error {
+func (i *LibpodAPI) ExportImage(call ioprojectatomicpodman.VarlinkCall, name, destination string, compress bool, tags []string, deterministic bool) error {
 	runtime, err := libpodruntime.GetRuntime(i.Cli)
 	if err != nil {
 		return call.ReplyRuntimeError(err.Error())
@@ -525,12 +1054,62 @@ func (i *LibpodAPI) ExportImage(call ioprojectatomicpodman.VarlinkCall, name, de
 		return err
 	}
 
-	if err := newImage.PushImage(getContext(), destination, "", "", "", nil, compress, image.SigningOptions{}, &image.DockerRegistryOptions{}, false, additionalTags); err != nil {
+	if deterministic {
+		err = newImage.ExportDeterministic(getContext(), destination, "", "", nil, additionalTags)
+	} else {
+		err = newImage.PushImage(getContext(), destination, "", "", "", nil, compress, image.SigningOptions{}, &image.DockerRegistryOptions{}, false, additionalTags)
+	}
+	if err != nil {
 		return call.ReplyErrorOccurred(err.Error())
 	}
 	return call.ReplyExportImage(newImage.ID())
 }
 
+// ImportImageSignatures stores externally provided signatures with an image
+func (i *LibpodAPI) ImportImageSignatures(call ioprojectatomicpodman.VarlinkCall, name string, signatures []string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	newImage, err := runtime.ImageRuntime().NewFromLocal(name)
+	if err != nil {
+		return call.ReplyImageNotFound(name)
+	}
+	var sigs [][]byte
+	for n, encoded := range signatures {
+		sig, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return call.ReplyErrorOccurred(fmt.Sprintf("unable to decode signature %d: %q", n, err))
+		}
+		sigs = append(sigs, sig)
+	}
+	if err := newImage.AddSignatures(getContext(), sigs); err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplyImportImageSignatures(newImage.ID())
+}
+
+// ExportImageSignatures returns the signatures stored with an image
+func (i *LibpodAPI) ExportImageSignatures(call ioprojectatomicpodman.VarlinkCall, name string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	newImage, err := runtime.ImageRuntime().NewFromLocal(name)
+	if err != nil {
+		return call.ReplyImageNotFound(name)
+	}
+	sigs, err := newImage.Signatures(getContext())
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	signatures := make([]string, 0, len(sigs))
+	for _, sig := range sigs {
+		signatures = append(signatures, base64.StdEncoding.EncodeToString(sig))
+	}
+	return call.ReplyExportImageSignatures(signatures)
+}
+
 // PullImage pulls an image from a registry to the image store.
 // TODO This implementation is incomplete
 func (i *LibpodAPI) PullImage(call ioprojectatomicpodman.VarlinkCall, name string) error {
@@ -544,3 +1123,341 @@ func (i *LibpodAPI) PullImage(call ioprojectatomicpodman.VarlinkCall, name strin
 	}
 	return call.ReplyPullImage(newImage.ID())
 }
+
+// PullIfNewer pulls an image unless the local image is current with its
+// registry
+func (i *LibpodAPI) PullIfNewer(call ioprojectatomicpodman.VarlinkCall, name string, tlsVerify bool) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	dockerRegistryOptions := image.DockerRegistryOptions{
+		DockerInsecureSkipTLSVerify: !tlsVerify,
+	}
+	newImage, pulled, err := runtime.ImageRuntime().PullIfNewer(getContext(), name, "", "", nil, &dockerRegistryOptions, image.SigningOptions{}, tlsVerify)
+	if err != nil {
+		return call.ReplyErrorOccurred(fmt.Sprintf("unable to pull %s: %s", name, err.Error()))
+	}
+	return call.ReplyPullIfNewer(newImage.ID(), pulled)
+}
+
+// PullImageVerified pulls an image only if it has a cosign signature which
+// verifies with the given public key
+func (i *LibpodAPI) PullImageVerified(call ioprojectatomicpodman.VarlinkCall, name, publicKey string, tlsVerify bool) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	dockerRegistryOptions := image.DockerRegistryOptions{
+		DockerInsecureSkipTLSVerify: !tlsVerify,
+	}
+	newImage, signer, err := runtime.ImageRuntime().PullImageVerified(getContext(), name, publicKey, "", "", nil, &dockerRegistryOptions)
+	if err != nil {
+		return call.ReplyErrorOccurred(fmt.Sprintf("unable to pull %s: %s", name, err.Error()))
+	}
+	return call.ReplyPullImageVerified(newImage.ID(), signer)
+}
+
+// NormalizeImageReference returns the fully qualified references pulling a
+// name would try
+func (i *LibpodAPI) NormalizeImageReference(call ioprojectatomicpodman.VarlinkCall, name string) error {
+	references, err := image.NormalizeImageReference(name)
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplyNormalizeImageReference(references)
+}
+
+// GetRemoteDigest returns the digest of the manifest a name currently resolves to
+// in its registry, without pulling the image
+func (i *LibpodAPI) GetRemoteDigest(call ioprojectatomicpodman.VarlinkCall, name string, tlsVerify bool, username, password string) error {
+	dockerRegistryOptions := image.DockerRegistryOptions{
+		DockerRegistryCreds:         getDockerAuth(username, password),
+		DockerInsecureSkipTLSVerify: !tlsVerify,
+	}
+	remoteDigest, err := image.GetRemoteDigest(getContext(), name, "", &dockerRegistryOptions)
+	if err != nil {
+		if errors.Cause(err) == image.ErrRemoteImageNotFound {
+			return call.ReplyImageNotFound(name)
+		}
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplyGetRemoteDigest(remoteDigest.String())
+}
+
+// ContainersByImage returns the containers in storage grouped by image
+func (i *LibpodAPI) ContainersByImage(call ioprojectatomicpodman.VarlinkCall, includeUnused bool) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	byImage, err := runtime.ImageRuntime().ContainersByImage(includeUnused)
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	images := make(map[string][]ioprojectatomicpodman.ImageContainer, len(byImage))
+	for imageID, containers := range byImage {
+		imageContainers := []ioprojectatomicpodman.ImageContainer{}
+		for _, c := range containers {
+			imageContainers = append(imageContainers, ioprojectatomicpodman.ImageContainer{
+				Id:    c.ID,
+				Names: c.Names,
+			})
+		}
+		images[imageID] = imageContainers
+	}
+	return call.ReplyContainersByImage(images)
+}
+
+// CheckImageUpdates reports the tags of local images which have moved in their
+// registries
+func (i *LibpodAPI) CheckImageUpdates(call ioprojectatomicpodman.VarlinkCall, tlsVerify bool) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	dockerRegistryOptions := image.DockerRegistryOptions{
+		DockerInsecureSkipTLSVerify: !tlsVerify,
+	}
+	updates, err := runtime.ImageRuntime().CheckImageUpdates(getContext(), "", &dockerRegistryOptions)
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	imageUpdates := []ioprojectatomicpodman.ImageUpdate{}
+	for _, u := range updates {
+		imageUpdate := ioprojectatomicpodman.ImageUpdate{
+			Id:            u.ID,
+			Name:          u.Name,
+			Local_digest:  u.LocalDigest.String(),
+			Remote_digest: u.RemoteDigest.String(),
+			Status:        string(u.Status),
+		}
+		if u.Error != nil {
+			imageUpdate.Error = u.Error.Error()
+		}
+		imageUpdates = append(imageUpdates, imageUpdate)
+	}
+	return call.ReplyCheckImageUpdates(imageUpdates)
+}
+
+// PullImageIndex caches the manifest list of an image in a registry and the
+// manifests of all its platforms
+func (i *LibpodAPI) PullImageIndex(call ioprojectatomicpodman.VarlinkCall, name string, tlsVerify bool) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	dockerRegistryOptions := image.DockerRegistryOptions{
+		DockerInsecureSkipTLSVerify: !tlsVerify,
+	}
+	index, err := runtime.ImageRuntime().PullImageIndex(getContext(), name, "", &dockerRegistryOptions)
+	if err != nil {
+		if errors.Cause(err) == image.ErrRemoteImageNotFound {
+			return call.ReplyImageNotFound(name)
+		}
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	var platforms []ioprojectatomicpodman.IndexPlatform
+	for _, p := range index.Platforms {
+		platforms = append(platforms, ioprojectatomicpodman.IndexPlatform{
+			Digest:       p.Digest.String(),
+			Media_type:   p.MediaType,
+			Os:           p.OS,
+			Architecture: p.Architecture,
+			Variant:      p.Variant,
+		})
+	}
+	return call.ReplyPullImageIndex(ioprojectatomicpodman.ImageIndex{
+		Digest:     index.Digest.String(),
+		Media_type: index.MediaType,
+		Platforms:  platforms,
+	})
+}
+
+// CreateManifestList creates a manifest list from local images
+func (i *LibpodAPI) CreateManifestList(call ioprojectatomicpodman.VarlinkCall, name string, images []string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	for _, imageName := range images {
+		if _, err := runtime.ImageRuntime().NewFromLocal(imageName); err != nil {
+			return call.ReplyImageNotFound(imageName)
+		}
+	}
+	list, err := runtime.ImageRuntime().CreateManifestList(getContext(), name, images)
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplyCreateManifestList(makeManifestList(list))
+}
+
+// AddToManifestList adds a local image to a manifest list
+func (i *LibpodAPI) AddToManifestList(call ioprojectatomicpodman.VarlinkCall, name, imageName string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	if _, err := runtime.ImageRuntime().NewFromLocal(imageName); err != nil {
+		return call.ReplyImageNotFound(imageName)
+	}
+	list, err := runtime.ImageRuntime().AddToManifestList(getContext(), name, imageName)
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplyAddToManifestList(makeManifestList(list))
+}
+
+// RemoveFromManifestList removes a manifest from a manifest list
+func (i *LibpodAPI) RemoveFromManifestList(call ioprojectatomicpodman.VarlinkCall, name, manifestDigest string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	list, err := runtime.ImageRuntime().RemoveFromManifestList(name, manifestDigest)
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplyRemoveFromManifestList(makeManifestList(list))
+}
+
+// makeManifestList converts a manifest list for a varlink reply
+func makeManifestList(list *image.ManifestList) ioprojectatomicpodman.ManifestList {
+	var members []ioprojectatomicpodman.ManifestListMember
+	for _, m := range list.Members {
+		members = append(members, ioprojectatomicpodman.ManifestListMember{
+			Image:        m.ImageID,
+			Digest:       m.Digest.String(),
+			Media_type:   m.MediaType,
+			Size:         m.Size,
+			Os:           m.OS,
+			Architecture: m.Architecture,
+			Variant:      m.Variant,
+		})
+	}
+	return ioprojectatomicpodman.ManifestList{
+		Name:       list.Name,
+		Digest:     list.Digest.String(),
+		Media_type: list.MediaType,
+		Members:    members,
+	}
+}
+
+// PushManifestList pushes a manifest list and its images to a registry
+func (i *LibpodAPI) PushManifestList(call ioprojectatomicpodman.VarlinkCall, name, destination string, tlsVerify bool) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	dockerRegistryOptions := image.DockerRegistryOptions{
+		DockerInsecureSkipTLSVerify: !tlsVerify,
+	}
+	listDigest, err := runtime.ImageRuntime().PushManifestList(getContext(), name, destination, "", nil, &dockerRegistryOptions, tlsVerify)
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplyPushManifestList(listDigest.String())
+}
+
+// ConvertImageFormat rewrites an image's manifest and configuration in the given
+// format and tags the result with a new name
+func (i *LibpodAPI) ConvertImageFormat(call ioprojectatomicpodman.VarlinkCall, name, newName, format string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	newImage, err := runtime.ImageRuntime().NewFromLocal(name)
+	if err != nil {
+		return call.ReplyImageNotFound(name)
+	}
+	convertedImage, err := newImage.ConvertFormat(getContext(), newName, format, nil)
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplyConvertImageFormat(convertedImage.ID())
+}
+
+// GetImageCommand returns the entrypoint and command of an image, and the
+// command line they combine into
+func (i *LibpodAPI) GetImageCommand(call ioprojectatomicpodman.VarlinkCall, name string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	newImage, err := runtime.ImageRuntime().NewFromLocal(name)
+	if err != nil {
+		return call.ReplyImageNotFound(name)
+	}
+	config, err := newImage.Config(getContext())
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplyGetImageCommand(makeImageCommand(config))
+}
+
+// makeImageCommand converts the entrypoint and command of an image config to
+// an ImageCommand
+func makeImageCommand(config *v1.ImageConfig) ioprojectatomicpodman.ImageCommand {
+	entrypoint := append([]string{}, config.Entrypoint...)
+	cmd := append([]string{}, config.Cmd...)
+	command := append(append([]string{}, entrypoint...), cmd...)
+	return ioprojectatomicpodman.ImageCommand{
+		Entrypoint: entrypoint,
+		Cmd:        cmd,
+		Command:    command,
+	}
+}
+
+// GetImageRunConfig returns the configuration a container run from an image
+// gets by default
+func (i *LibpodAPI) GetImageRunConfig(call ioprojectatomicpodman.VarlinkCall, name string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	newImage, err := runtime.ImageRuntime().NewFromLocal(name)
+	if err != nil {
+		return call.ReplyImageNotFound(name)
+	}
+	config, err := newImage.Config(getContext())
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	exposedPorts := []string{}
+	for port := range config.ExposedPorts {
+		exposedPorts = append(exposedPorts, port)
+	}
+	sort.Strings(exposedPorts)
+	volumes := []string{}
+	for volume := range config.Volumes {
+		volumes = append(volumes, volume)
+	}
+	sort.Strings(volumes)
+	labels := make(map[string]string, len(config.Labels))
+	for k, v := range config.Labels {
+		labels[k] = v
+	}
+	stopSignal := config.StopSignal
+	if stopSignal == "" {
+		stopSignal = "SIGTERM"
+	}
+	return call.ReplyGetImageRunConfig(ioprojectatomicpodman.ImageRunConfig{
+		Command:       makeImageCommand(config),
+		Env:           append([]string{}, config.Env...),
+		User:          config.User,
+		Work_dir:      config.WorkingDir,
+		Exposed_ports: exposedPorts,
+		Volumes:       volumes,
+		Labels:        labels,
+		Stop_signal:   stopSignal,
+	})
+}
+
+// ImagesExist reports whether each of the given images is present in local storage
+func (i *LibpodAPI) ImagesExist(call ioprojectatomicpodman.VarlinkCall, names []string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	return call.ReplyImagesExist(runtime.ImageRuntime().ImagesExist(names))
+}
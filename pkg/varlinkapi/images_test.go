@@ -0,0 +1,89 @@
+package varlinkapi
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containers/image/types"
+	"github.com/containers/storage"
+	"github.com/projectatomic/buildah"
+	"github.com/projectatomic/buildah/imagebuildah"
+	"github.com/projectatomic/libpod/libpod/image"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDockerfilesContext(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "varlinkapi-test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	base := filepath.Join(tmpDir, "Dockerfile.base")
+	app := filepath.Join(tmpDir, "Dockerfile.app")
+	assert.NoError(t, ioutil.WriteFile(base, []byte("FROM alpine\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(app, []byte("RUN touch /app\n"), 0644))
+
+	contextDir, dockerfiles, err := dockerfilesContext([]string{base, app, "https://example.com/Dockerfile"})
+	assert.NoError(t, err)
+	assert.Equal(t, tmpDir, contextDir)
+	assert.Equal(t, []string{"Dockerfile.base", "Dockerfile.app", "https://example.com/Dockerfile"}, dockerfiles)
+}
+
+func TestDockerfilesContextDifferentDirectories(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "varlinkapi-test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	assert.NoError(t, os.Mkdir(filepath.Join(tmpDir, "sub"), 0755))
+	base := filepath.Join(tmpDir, "Dockerfile")
+	app := filepath.Join(tmpDir, "sub", "Dockerfile")
+
+	_, _, err = dockerfilesContext([]string{base, app})
+	assert.Error(t, err)
+}
+
+func TestEnforceImageSizeLimit(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "varlinkapi-test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	store, err := storage.GetStore(storage.StoreOptions{
+		RunRoot:         filepath.Join(tmpDir, "run"),
+		GraphRoot:       filepath.Join(tmpDir, "root"),
+		GraphDriverName: "vfs",
+	})
+	assert.NoError(t, err)
+	defer store.Shutdown(true)
+	ir := image.NewImageRuntimeFromStore(store)
+
+	contextDir := filepath.Join(tmpDir, "context")
+	assert.NoError(t, os.Mkdir(contextDir, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(contextDir, "data"), make([]byte, 64*1024), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(contextDir, "Dockerfile"), []byte("FROM scratch\nCOPY data /data\n"), 0644))
+
+	ctx := context.Background()
+	options := imagebuildah.BuildOptions{
+		ContextDirectory: contextDir,
+		PullPolicy:       imagebuildah.PullNever,
+		OutputFormat:     imagebuildah.OCIv1ImageFormat,
+		Output:           "localhost/sized:latest",
+		ReportWriter:     ioutil.Discard,
+		SystemContext:    &types.SystemContext{},
+		CommonBuildOpts:  &buildah.CommonBuildOptions{},
+	}
+	assert.NoError(t, imagebuildah.BuildDockerfiles(ctx, store, options, "Dockerfile"))
+
+	img, err := ir.NewFromLocal("localhost/sized:latest")
+	assert.NoError(t, err)
+	assert.NoError(t, enforceImageSizeLimit(ctx, img, 1024*1024))
+	_, err = ir.NewFromLocal("localhost/sized:latest")
+	assert.NoError(t, err)
+
+	err = enforceImageSizeLimit(ctx, img, 1024)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "larger than the maximum size of 1.024kB (1024 bytes)")
+	_, err = ir.NewFromLocal("localhost/sized:latest")
+	assert.Error(t, err)
+}
@@ -0,0 +1,277 @@
+package varlinkapi
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectatomic/libpod/cmd/podman/libpodruntime"
+	"github.com/projectatomic/libpod/cmd/podman/varlink"
+	"github.com/projectatomic/libpod/libpod"
+)
+
+// StreamPodEvents streams pod lifecycle events until the client disconnects
+func (i *LibpodAPI) StreamPodEvents(call ioprojectatomicpodman.VarlinkCall) error {
+	if !call.WantsMore() {
+		return call.ReplyErrorOccurred("StreamPodEvents must be called with the more flag")
+	}
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	sub := runtime.SubscribePodEvents()
+	defer sub.Close()
+
+	call.Continues = true
+	for event := range sub.Events() {
+		podEvent := ioprojectatomicpodman.PodEvent{
+			Pod_id:          event.PodID,
+			Type:            string(event.Type),
+			Container_id:    event.ContainerID,
+			Container_state: event.ContainerState,
+			Dropped:         int64(event.Dropped),
+			Time:            event.Time.Format(time.RFC3339Nano),
+		}
+		// Replying fails once the client has gone away
+		if err := call.ReplyStreamPodEvents(podEvent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListOrphanedPods returns the pods whose state is inconsistent
+func (i *LibpodAPI) ListOrphanedPods(call ioprojectatomicpodman.VarlinkCall) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	orphaned, err := runtime.ListOrphanedPods()
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	pods := []ioprojectatomicpodman.OrphanedPod{}
+	for _, o := range orphaned {
+		pods = append(pods, ioprojectatomicpodman.OrphanedPod{
+			Id:       o.Pod.ID(),
+			Name:     o.Pod.Name(),
+			Problems: o.Problems,
+		})
+	}
+	return call.ReplyListOrphanedPods(pods)
+}
+
+// ListOrphanedPodVolumes returns the volumes left behind by removed pods
+func (i *LibpodAPI) ListOrphanedPodVolumes(call ioprojectatomicpodman.VarlinkCall) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	orphaned, err := runtime.ListOrphanedPodVolumes()
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	volumes := []ioprojectatomicpodman.OrphanedPodVolume{}
+	for _, o := range orphaned {
+		volumes = append(volumes, ioprojectatomicpodman.OrphanedPodVolume{
+			Pod:  o.Pod,
+			Name: o.Name,
+			Path: o.Path,
+			Size: o.Size,
+		})
+	}
+	return call.ReplyListOrphanedPodVolumes(volumes)
+}
+
+// InspectPodDetailed returns a pod's configuration, containers and resource
+// usage
+func (i *LibpodAPI) InspectPodDetailed(call ioprojectatomicpodman.VarlinkCall, name string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	pod, err := runtime.LookupPod(name)
+	if err != nil {
+		return call.ReplyPodNotFound(name)
+	}
+	cgroupPath, err := pod.CgroupPath()
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	ctrs, err := pod.AllContainers()
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	usage, err := pod.Usage()
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+
+	containers := []ioprojectatomicpodman.PodContainerDetail{}
+	for _, ctr := range ctrs {
+		state, err := ctr.State()
+		if err != nil {
+			return call.ReplyErrorOccurred(err.Error())
+		}
+		detail := ioprojectatomicpodman.PodContainerDetail{
+			Id:    ctr.ID(),
+			Name:  ctr.Name(),
+			State: state.String(),
+		}
+		if ctrUsage, ok := usage.Containers[ctr.ID()]; ok {
+			detail.Usage = &ioprojectatomicpodman.ResourceUsage{
+				Cpu_nano:  int64(ctrUsage.CPUNano),
+				Mem_usage: int64(ctrUsage.MemUsage),
+				Pids:      int64(ctrUsage.PIDs),
+			}
+		}
+		containers = append(containers, detail)
+	}
+
+	return call.ReplyInspectPodDetailed(ioprojectatomicpodman.PodDetail{
+		Id:             pod.ID(),
+		Name:           pod.Name(),
+		Labels:         pod.Labels(),
+		Cgroup_parent:  pod.CgroupParent(),
+		Cgroup_path:    cgroupPath,
+		Use_pod_cgroup: pod.UsePodCgroup(),
+		Containers:     containers,
+		Usage: ioprojectatomicpodman.ResourceUsage{
+			Cpu_nano:  int64(usage.CPUNano),
+			Mem_usage: int64(usage.MemUsage),
+			Pids:      int64(usage.PIDs),
+		},
+		Usage_from_pod_cgroup: usage.FromPodCgroup,
+	})
+}
+
+// GetPodStartOrder returns the IDs of a pod's containers in the order they
+// would be started
+func (i *LibpodAPI) GetPodStartOrder(call ioprojectatomicpodman.VarlinkCall, name string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	pod, err := runtime.LookupPod(name)
+	if err != nil {
+		return call.ReplyPodNotFound(name)
+	}
+	order, err := pod.StartOrder()
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplyGetPodStartOrder(order)
+}
+
+// RunPodContainerHealthcheck runs the healthcheck of a container in a pod
+func (i *LibpodAPI) RunPodContainerHealthcheck(call ioprojectatomicpodman.VarlinkCall, podName, ctrName string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	result, err := runtime.RunPodContainerHealthcheck(getContext(), podName, ctrName)
+	if err != nil {
+		switch errors.Cause(err) {
+		case libpod.ErrNoSuchPod:
+			return call.ReplyPodNotFound(podName)
+		case libpod.ErrNoSuchCtr:
+			return call.ReplyContainerNotFound(ctrName)
+		}
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	return call.ReplyRunPodContainerHealthcheck(int64(result.ExitCode), result.Output)
+}
+
+// RelabelPod applies an SELinux label to all containers in a pod
+func (i *LibpodAPI) RelabelPod(call ioprojectatomicpodman.VarlinkCall, name, mountLabel string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	pod, err := runtime.LookupPod(name)
+	if err != nil {
+		return call.ReplyPodNotFound(name)
+	}
+	ctrErrors, err := runtime.RelabelPod(pod, mountLabel)
+	if err != nil && ctrErrors == nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	errs := make(map[string]string)
+	for id, ctrErr := range ctrErrors {
+		errs[id] = ctrErr.Error()
+	}
+	return call.ReplyRelabelPod(errs)
+}
+
+// GetPodExitCodes returns the exit codes of the containers in a pod
+func (i *LibpodAPI) GetPodExitCodes(call ioprojectatomicpodman.VarlinkCall, name string) error {
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	pod, err := runtime.LookupPod(name)
+	if err != nil {
+		return call.ReplyPodNotFound(name)
+	}
+	exitCodes, err := pod.ExitCodes()
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	codes := make(map[string]int64, len(exitCodes))
+	for ctrName, exitCode := range exitCodes {
+		codes[ctrName] = int64(exitCode)
+	}
+	return call.ReplyGetPodExitCodes(codes)
+}
+
+// GetPodLogs returns the merged logs of the containers of a pod, following
+// them if asked to
+func (i *LibpodAPI) GetPodLogs(call ioprojectatomicpodman.VarlinkCall, name string, follow bool, tail int64) error {
+	if follow && !call.WantsMore() {
+		return call.ReplyErrorOccurred("GetPodLogs must be called with the more flag to follow the logs")
+	}
+	runtime, err := libpodruntime.GetRuntime(i.Cli)
+	if err != nil {
+		return call.ReplyRuntimeError(err.Error())
+	}
+	pod, err := runtime.LookupPod(name)
+	if err != nil {
+		return call.ReplyPodNotFound(name)
+	}
+	lines, follower, err := pod.FollowLogs(int(tail))
+	if err != nil {
+		return call.ReplyErrorOccurred(err.Error())
+	}
+	defer follower.Close()
+	if !follow {
+		return call.ReplyGetPodLogs(podLogLines(lines))
+	}
+
+	call.Continues = true
+	for {
+		// Replying fails once the client has gone away
+		if err := call.ReplyGetPodLogs(podLogLines(lines)); err != nil {
+			return err
+		}
+		time.Sleep(1 * time.Second)
+		lines, err = follower.Next()
+		if err != nil {
+			call.Continues = false
+			if errors.Cause(err) == libpod.ErrNoSuchPod {
+				// The pod is gone; end the stream with the rest
+				// of its logs
+				return call.ReplyGetPodLogs(podLogLines(lines))
+			}
+			return call.ReplyErrorOccurred(err.Error())
+		}
+	}
+}
+
+// podLogLines prefixes lines logged in a pod with the names of the containers
+// which logged them
+func podLogLines(lines []libpod.PodLogLine) []string {
+	logs := make([]string, 0, len(lines))
+	for _, line := range lines {
+		logs = append(logs, line.ContainerName+": "+line.Line)
+	}
+	return logs
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/containers/image/types"
 	"github.com/projectatomic/libpod/cmd/podman/batchcontainer"
 	"github.com/projectatomic/libpod/cmd/podman/varlink"
 	"github.com/projectatomic/libpod/libpod"
@@ -15,6 +16,18 @@ func getContext() context.Context {
 	return context.TODO()
 }
 
+// getDockerAuth returns the registry credentials for the given username and
+// password, or nil if no username was given
+func getDockerAuth(username, password string) *types.DockerAuthConfig {
+	if username == "" {
+		return nil
+	}
+	return &types.DockerAuthConfig{
+		Username: username,
+		Password: password,
+	}
+}
+
 func makeListContainer(containerID string, batchInfo batchcontainer.BatchContainerStruct) ioprojectatomicpodman.ListContainerData {
 	var (
 		mounts []ioprojectatomicpodman.ContainerMount